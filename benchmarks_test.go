@@ -11,7 +11,7 @@ func BenchmarkSimpleParsing(bench *testing.B) {
   query := "SELECT [foo] FROM bar WHERE [baz] = :quux"
   for i := 0; i < bench.N; i++ {
 
-    NewNamedParameterQuery(query)
+    NewNamedParameterQuery(query, "?")
   }
 }
 
@@ -23,7 +23,7 @@ func BenchmarkMultiOccurrenceParsing(bench *testing.B) {
 
   for i := 0; i < bench.N; i++ {
 
-    NewNamedParameterQuery(query)
+    NewNamedParameterQuery(query, "?")
   }
 }
 
@@ -35,7 +35,7 @@ func BenchmarkMultiParameterParsing(bench *testing.B) {
 
   for i := 0; i < bench.N; i++ {
 
-    NewNamedParameterQuery(query)
+    NewNamedParameterQuery(query, "?")
   }
 }
 
@@ -45,7 +45,7 @@ func BenchmarkMultiParameterParsing(bench *testing.B) {
 func BenchmarkNoReplacement(bench *testing.B) {
 
   query := "SELECT [foo] FROM bar WHERE [baz] = quux"
-  replacer := NewNamedParameterQuery(query)
+  replacer := NewNamedParameterQuery(query, "?")
 
   for i := 0; i < bench.N; i++ {
 
@@ -53,13 +53,45 @@ func BenchmarkNoReplacement(bench *testing.B) {
   }
 }
 
+/*
+  Benchmarks extracting the names of a query's parameters, without binding anything
+*/
+func BenchmarkExtractParameterNames(bench *testing.B) {
+
+  query := "SELECT [foo] FROM bar WHERE [baz] = :quux " +
+            "AND [something] = :quux2 " +
+            "OR [otherStuff] NOT :quux"
+
+  bench.ReportAllocs()
+
+  for i := 0; i < bench.N; i++ {
+
+    ExtractParameterNames(query, "?")
+  }
+}
+
+/*
+  Benchmarks parsing a static query, which has nothing to replace
+*/
+func BenchmarkNoParameterParsing(bench *testing.B) {
+
+  query := "SELECT foo, bar FROM baz WHERE quux = 1 AND corge IS NOT NULL ORDER BY grault"
+
+  bench.ReportAllocs()
+
+  for i := 0; i < bench.N; i++ {
+
+    NewNamedParameterQuery(query, "?")
+  }
+}
+
 /*
   Benchmarks returning a query which uses exactly one parameter
 */
 func BenchmarkSingleReplacement(bench *testing.B) {
 
   query := "SELECT [foo] FROM bar WHERE [baz] = :quux"
-  replacer := NewNamedParameterQuery(query)
+  replacer := NewNamedParameterQuery(query, "?")
 
   for i := 0; i < bench.N; i++ {
 
@@ -76,7 +108,7 @@ func BenchmarkMultiOccurrenceReplacement(bench *testing.B) {
   query := "SELECT [foo] FROM bar WHERE [baz] = :quux " +
             "AND [something] = :quux " +
             "OR [otherStuff] NOT :quux"
-  replacer := NewNamedParameterQuery(query)
+  replacer := NewNamedParameterQuery(query, "?")
 
   for i := 0; i < bench.N; i++ {
 
@@ -93,7 +125,7 @@ func BenchmarkMultiParameterReplacement(bench *testing.B) {
   query := "SELECT [foo] FROM bar WHERE [baz] = :quux " +
             "AND [something] = :quux2 " +
             "OR [otherStuff] NOT :quux3 "
-  replacer := NewNamedParameterQuery(query)
+  replacer := NewNamedParameterQuery(query, "?")
 
   for i := 0; i < bench.N; i++ {
 
@@ -136,7 +168,7 @@ func benchmarkMultiParameter(bench *testing.B, parameterCount int) {
     queryBuffer.WriteString(fmt.Sprintf(queryLine, i))
   }
 
-  replacer := NewNamedParameterQuery(queryBuffer.String())
+  replacer := NewNamedParameterQuery(queryBuffer.String(), "?")
 
   for i := 0; i < bench.N; i++ {
 
@@ -148,3 +180,71 @@ func benchmarkMultiParameter(bench *testing.B, parameterCount int) {
     replacer.GetParsedParameters()
   }
 }
+
+/*
+  Benchmarks parsing then binding a query with a handful of distinct parameters,
+  the common case which parameter lookups are tuned for.
+*/
+func BenchmarkEightParameterParsingAndReplacement(bench *testing.B) {
+
+  query := "SELECT [foo] FROM bar WHERE [a] = :a AND [b] = :b AND [c] = :c AND [d] = :d " +
+            "AND [e] = :e AND [f] = :f AND [g] = :g AND [h] = :h AND [aa] = :a"
+  names := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+
+  for i := 0; i < bench.N; i++ {
+
+    replacer := NewNamedParameterQuery(query, "?")
+
+    for _, name := range names {
+      replacer.SetValue(name, i)
+    }
+
+    replacer.GetParsedParameters()
+  }
+}
+
+/*
+  Benchmarks the allocations made while parsing a long query,
+  whose revised query buffer would otherwise be grown from scratch every time.
+*/
+func BenchmarkLongQueryParsingAllocations(bench *testing.B) {
+
+  var queryBuffer bytes.Buffer
+
+  queryBuffer.WriteString("SELECT [foo] FROM bar WHERE 1 = 1")
+  for i := 0; i < 64; i++ {
+    queryBuffer.WriteString(fmt.Sprintf(" AND [column%d] = :quux%d", i, i%8))
+  }
+  query := queryBuffer.String()
+
+  bench.ReportAllocs()
+  bench.ResetTimer()
+
+  for i := 0; i < bench.N; i++ {
+
+    NewNamedParameterQuery(query, "?")
+  }
+}
+
+/*
+  Benchmarks cloning the same long query, which should allocate as little as cloning a short one,
+  since nothing is re-parsed.
+*/
+func BenchmarkLongQueryClone(bench *testing.B) {
+
+  var queryBuffer bytes.Buffer
+
+  queryBuffer.WriteString("SELECT [foo] FROM bar WHERE 1 = 1")
+  for i := 0; i < 64; i++ {
+    queryBuffer.WriteString(fmt.Sprintf(" AND [column%d] = :quux%d", i, i%8))
+  }
+  replacer := NewNamedParameterQuery(queryBuffer.String(), "?")
+
+  bench.ReportAllocs()
+  bench.ResetTimer()
+
+  for i := 0; i < bench.N; i++ {
+
+    replacer.Clone()
+  }
+}
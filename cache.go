@@ -0,0 +1,128 @@
+package namedParameterQuery
+
+import (
+	"container/list"
+	"sync"
+)
+
+/*
+	The number of parsed queries kept by GetCachedNamedParameterQuery when SetQueryCacheSize was never called.
+*/
+const defaultQueryCacheSize = 256
+
+/*
+	queryCacheKey identifies a parsed query in the cache.
+*/
+type queryCacheKey struct {
+	queryText string
+	argIndication string
+}
+
+/*
+	queryCacheEntry is the value of the elements of the cache's recency list.
+*/
+type queryCacheEntry struct {
+	key queryCacheKey
+	query *NamedParameterQuery
+}
+
+/*
+	queryCache is a size-capped cache of parsed queries, which evicts the least recently used one when full.
+*/
+type queryCache struct {
+	lock sync.Mutex
+
+	// The maximum number of queries kept. Zero or less disables the cache.
+	size int
+
+	// The cached queries, the most recently used first.
+	recency *list.List
+
+	// The elements of recency, by key.
+	elements map[queryCacheKey]*list.Element
+}
+
+var cache = &queryCache{
+	size: defaultQueryCacheSize,
+	recency: list.New(),
+	elements: make(map[queryCacheKey]*list.Element),
+}
+
+/*
+	GetCachedNamedParameterQuery works like NewNamedParameterQuery, but only parses a given [queryText] and [argIndication]
+	pair once: later calls return the very same *NamedParameterQuery, until it is evicted from the cache.
+	The returned query is shared, and must be treated as a read-only template: bind values to it with Bind,
+	which doesn't modify the query, and never with SetValue or the other setters.
+	It is safe for concurrent use.
+*/
+func GetCachedNamedParameterQuery(queryText string, argIndication string) *NamedParameterQuery {
+	return cache.get(queryCacheKey{queryText, argIndication})
+}
+
+/*
+	SetQueryCacheSize sets the maximum number of parsed queries kept by GetCachedNamedParameterQuery to [size],
+	evicting the least recently used ones if there are more already. A [size] of zero or less disables the cache.
+	The default size is 256.
+*/
+func SetQueryCacheSize(size int) {
+
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	cache.size = size
+	cache.evict()
+}
+
+/*
+	get returns the parsed query for [key], parsing and caching it if it isn't cached yet.
+*/
+func (qc *queryCache) get(key queryCacheKey) *NamedParameterQuery {
+
+	var element *list.Element
+	var query *NamedParameterQuery
+	var present bool
+
+	qc.lock.Lock()
+
+	element, present = qc.elements[key]
+	if present {
+		qc.recency.MoveToFront(element)
+		qc.lock.Unlock()
+		return element.Value.(*queryCacheEntry).query
+	}
+	qc.lock.Unlock()
+
+	// parsing is done without holding the lock; two goroutines may then parse the same query, which is harmless.
+	query = NewNamedParameterQuery(key.queryText, key.argIndication)
+
+	qc.lock.Lock()
+	defer qc.lock.Unlock()
+
+	element, present = qc.elements[key]
+	if present {
+		qc.recency.MoveToFront(element)
+		return element.Value.(*queryCacheEntry).query
+	}
+
+	if qc.size > 0 {
+		qc.elements[key] = qc.recency.PushFront(&queryCacheEntry{key, query})
+		qc.evict()
+	}
+	return query
+}
+
+/*
+	evict drops the least recently used queries until there are no more than the cache size.
+	The lock must be held.
+*/
+func (qc *queryCache) evict() {
+
+	var element *list.Element
+
+	for qc.recency.Len() > 0 && qc.recency.Len() > qc.size {
+
+		element = qc.recency.Back()
+		qc.recency.Remove(element)
+		delete(qc.elements, element.Value.(*queryCacheEntry).key)
+	}
+}
@@ -0,0 +1,87 @@
+package namedParameterQuery
+
+import (
+	"testing"
+)
+
+func TestCachedNamedParameterQuery(test *testing.T) {
+
+	var first *NamedParameterQuery
+	var second *NamedParameterQuery
+	var parameters []interface{}
+	var err error
+
+	defer SetQueryCacheSize(defaultQueryCacheSize)
+
+	first = GetCachedNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar", "$")
+	second = GetCachedNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar", "$")
+
+	if(first != second) {
+		test.Log("Test CachedQueryIsReused: Expected the same query for the same text")
+		test.Fail()
+	}
+
+	if(first.GetParsedQuery() != "SELECT * FROM table WHERE col1 = $1 AND col2 = $2") {
+		test.Log("Test CachedQueryIsParsed: Actual query: ", first.GetParsedQuery())
+		test.Fail()
+	}
+
+	parameters, err = first.Bind(map[string]interface{} {
+		"foo": 1,
+		"bar": "two",
+	})
+	if(err != nil) {
+		test.Log("Test CachedQueryBind: unexpected error: ", err)
+		test.Fail()
+	}
+	verifyParameters("CachedQueryBind", test, parameters, []interface{} {
+		1,
+		"two",
+	})
+
+	// the arg indication is part of the key.
+	second = GetCachedNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar", "?")
+	if(first == second || second.GetParsedQuery() != "SELECT * FROM table WHERE col1 = ? AND col2 = ?") {
+		test.Log("Test CachedQueryByArgIndication: Actual query: ", second.GetParsedQuery())
+		test.Fail()
+	}
+}
+
+func TestQueryCacheEviction(test *testing.T) {
+
+	var first *NamedParameterQuery
+	var second *NamedParameterQuery
+
+	defer SetQueryCacheSize(defaultQueryCacheSize)
+
+	SetQueryCacheSize(2)
+
+	first = GetCachedNamedParameterQuery("SELECT :a", "?")
+	second = GetCachedNamedParameterQuery("SELECT :b", "?")
+
+	// using the first query again makes the second one the least recently used.
+	GetCachedNamedParameterQuery("SELECT :a", "?")
+	GetCachedNamedParameterQuery("SELECT :c", "?")
+
+	if(cache.recency.Len() != 2) {
+		test.Log("Test CacheIsCapped: Expected 2 cached queries, Actual: ", cache.recency.Len())
+		test.Fail()
+	}
+
+	if(GetCachedNamedParameterQuery("SELECT :a", "?") != first) {
+		test.Log("Test RecentlyUsedQueryIsKept: Expected the cached query")
+		test.Fail()
+	}
+
+	if(GetCachedNamedParameterQuery("SELECT :b", "?") == second) {
+		test.Log("Test LeastRecentlyUsedQueryIsEvicted: Expected a newly parsed query")
+		test.Fail()
+	}
+
+	SetQueryCacheSize(0)
+
+	if(GetCachedNamedParameterQuery("SELECT :a", "?") == GetCachedNamedParameterQuery("SELECT :a", "?")) {
+		test.Log("Test DisabledCache: Expected newly parsed queries")
+		test.Fail()
+	}
+}
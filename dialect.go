@@ -0,0 +1,179 @@
+package namedParameterQuery
+
+import (
+	"fmt"
+)
+
+/*
+	SQLDialect writes the placeholders of the revised query, for the flavour of SQL the database driver expects.
+	The Dialect constants implement it, and so can dialects defined outside of npq package,
+	e.g. for ClickHouse or DuckDB; see NewNamedParameterQueryForDialect.
+*/
+type SQLDialect interface {
+
+	// Placeholder returns the placeholder written for an occurrence of the parameter [name]
+	// whose value is the [ordinal]th one in GetParsedParameters, counting from 1.
+	Placeholder(name string, ordinal int) string
+
+	// ReusesOrdinals returns true if every occurrence of a parameter must be written with the same ordinal,
+	// so that its value is bound once. Otherwise, each occurrence has its own ordinal, unless WithReusedPlaceholders is given.
+	ReusesOrdinals() bool
+
+	// PreservesNames returns true if the placeholders are made of the parameter names,
+	// so that values are bound by name; see GetParsedArguments.
+	PreservesNames() bool
+}
+
+/*
+	Dialect tells which positional placeholders are written in the revised query,
+	i.e. the flavour of SQL the database driver expects.
+*/
+type Dialect int
+
+const (
+	// DialectMySQL writes "?" placeholders, as MySQL and SQLite expect.
+	DialectMySQL Dialect = iota + 1
+
+	// DialectPostgres writes numbered "$1", "$2", ... placeholders.
+	DialectPostgres
+
+	// DialectOracle writes numbered ":1", ":2", ... bind variables, in the order parameters are found.
+	// A parameter used several times gets a new number each time, since Oracle binds positional arguments
+	// of SQL statements by placeholder position, not by number.
+	DialectOracle
+
+	// DialectSQLServer writes numbered "@p1", "@p2", ... placeholders. Every occurrence of a parameter
+	// reuses the same placeholder, so its value is bound once; see GetParsedNamedArgs.
+	DialectSQLServer
+
+	// DialectNamed keeps the names, writing ":name" placeholders, for drivers which bind named arguments themselves.
+	DialectNamed
+
+	// DialectSQLite writes numbered "?1", "?2", ... placeholders. Every occurrence of a parameter
+	// reuses the same placeholder, which collapses duplicate positions: GetParsedParameters then
+	// has one value per distinct parameter.
+	DialectSQLite
+
+	// DialectPgx keeps the names, writing "@name" placeholders, for pgx named arguments; see GetPgxNamedArgs.
+	DialectPgx
+)
+
+/*
+	String returns the name of the dialect.
+*/
+func (dialect Dialect) String() string {
+
+	switch dialect {
+	case DialectMySQL:
+		return "MySQL"
+	case DialectPostgres:
+		return "PostgreSQL"
+	case DialectOracle:
+		return "Oracle"
+	case DialectSQLServer:
+		return "SQL Server"
+	case DialectNamed:
+		return "named"
+	case DialectSQLite:
+		return "SQLite"
+	case DialectPgx:
+		return "pgx"
+	}
+	return fmt.Sprintf("Dialect(%d)", int(dialect))
+}
+
+/*
+	Placeholder returns the placeholder written for the occurrence of the parameter [name]
+	whose value is the [ordinal]th one, counting from 1.
+*/
+func (dialect Dialect) Placeholder(name string, ordinal int) string {
+
+	switch dialect {
+	case DialectNamed:
+		return ":" + name
+	case DialectPostgres:
+		return fmt.Sprintf("$%d", ordinal)
+	case DialectOracle:
+		return fmt.Sprintf(":%d", ordinal)
+	case DialectSQLServer:
+		return fmt.Sprintf("@p%d", ordinal)
+	case DialectSQLite:
+		return fmt.Sprintf("?%d", ordinal)
+	case DialectPgx:
+		return "@" + name
+	}
+	return "?"
+}
+
+/*
+	ReusesOrdinals returns true for DialectSQLServer and DialectSQLite, whose placeholders are reused for every
+	occurrence of a parameter.
+*/
+func (dialect Dialect) ReusesOrdinals() bool {
+	return dialect == DialectSQLServer || dialect == DialectSQLite
+}
+
+/*
+	PreservesNames returns true for DialectNamed and DialectPgx, whose placeholders are the parameter names.
+*/
+func (dialect Dialect) PreservesNames() bool {
+	return dialect == DialectNamed || dialect == DialectPgx
+}
+
+/*
+	valid returns true if [dialect] is one of the Dialect constants.
+*/
+func (dialect Dialect) valid() bool {
+	return dialect >= DialectMySQL && dialect <= DialectPgx
+}
+
+/*
+	dialectOf returns the dialect for the given [argIndication] string:
+	":" is DialectNamed, "$" DialectPostgres, ":N" DialectOracle, "?N" DialectSQLite, and anything else DialectMySQL.
+*/
+func dialectOf(argIndication string) Dialect {
+
+	switch argIndication {
+	case ":":
+		return DialectNamed
+	case "$":
+		return DialectPostgres
+	case ":N":
+		return DialectOracle
+	case "?N":
+		return DialectSQLite
+	}
+	return DialectMySQL
+}
+
+/*
+	parseArgIndication returns the dialect for the given [argIndication] string, like dialectOf,
+	but returns an error for anything else than "?", "$", ":", ":N" and "?N".
+*/
+func parseArgIndication(argIndication string) (Dialect, error) {
+
+	switch argIndication {
+	case "?", "$", ":", ":N", "?N":
+		return dialectOf(argIndication), nil
+	}
+	return 0, fmt.Errorf("unable to create query: unknown arg indication \"%s\", expected one of \"?\", \"$\", \":\", \":N\" or \"?N\"", argIndication)
+}
+
+/*
+	NewNamedParameterQueryForDialect works like ParseNamedParameterQuery, but takes the placeholders to write
+	as a [dialect] rather than an argIndication string: either one of the Dialect constants,
+	or any other implementation of SQLDialect. A Dialect which isn't one of the constants is an error, and so is nil.
+	Parsing rules which depend on the placeholders, such as rejecting "?" already in the query, only apply to the constants.
+*/
+func NewNamedParameterQueryForDialect(queryText string, dialect SQLDialect, options ...Option) (*NamedParameterQuery, error) {
+
+	var builtin Dialect
+	var isBuiltin bool
+
+	builtin, isBuiltin = dialect.(Dialect)
+
+	if dialect == nil || (isBuiltin && !builtin.valid()) {
+		return nil, fmt.Errorf("unable to create query: unknown dialect %v", dialect)
+	}
+	return parseNamedParameterQuery(queryText, dialect, options)
+}
@@ -0,0 +1,503 @@
+package namedParameterQuery
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+)
+
+func TestDialects(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	expected := map[Dialect]string {
+		DialectMySQL: "SELECT * FROM table WHERE col1 = ? AND col2 = ? AND col3 = ?",
+		DialectPostgres: "SELECT * FROM table WHERE col1 = $1 AND col2 = $2 AND col3 = $3",
+		DialectOracle: "SELECT * FROM table WHERE col1 = :1 AND col2 = :2 AND col3 = :3",
+		DialectSQLServer: "SELECT * FROM table WHERE col1 = @p1 AND col2 = @p2 AND col3 = @p1",
+		DialectNamed: "SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo",
+		DialectSQLite: "SELECT * FROM table WHERE col1 = ?1 AND col2 = ?2 AND col3 = ?1",
+		DialectPgx: "SELECT * FROM table WHERE col1 = @foo AND col2 = @bar AND col3 = @foo",
+	}
+
+	for dialect, expectedQuery := range expected {
+
+		query, err = NewNamedParameterQueryForDialect("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo", dialect)
+		if(err != nil) {
+			test.Log("Test '", dialect, "': unexpected error: ", err)
+			test.Fail()
+			continue
+		}
+
+		if(query.GetParsedQuery() != expectedQuery) {
+			test.Log("Test '", dialect, "': Expected query: ", expectedQuery, ", Actual: ", query.GetParsedQuery())
+			test.Fail()
+		}
+
+		if(query.GetParameterCount() != 3 && !query.reusesOrdinals()) {
+			test.Log("Test '", dialect, "': Expected 3 parameters, Actual: ", query.GetParameterCount())
+			test.Fail()
+		}
+	}
+}
+
+func TestSQLServerNamedArgs(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	query, err = NewNamedParameterQueryForDialect("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo", DialectSQLServer)
+	if(err != nil) {
+		test.Log("Test SQLServerNamedArgs: unexpected error: ", err)
+		test.FailNow()
+	}
+
+	query.SetValue("foo", 1)
+	query.SetValue("bar", "two")
+
+	verifyStructParameters("SQLServerParameters", test, query, []interface{} {
+		1,
+		"two",
+	})
+
+	verifyParameters("SQLServerNamedArgs", test, query.GetParsedNamedArgs(), []interface{} {
+		sql.Named("p1", 1),
+		sql.Named("p2", "two"),
+	})
+
+	if(query.GetParameterOffsets()["foo"][1] != 65) {
+		test.Log("Test SQLServerOffsets: Expected every occurrence offset, Actual: ", query.GetParameterOffsets()["foo"])
+		test.Fail()
+	}
+}
+
+func TestUnknownDialect(test *testing.T) {
+
+	var err error
+
+	for _, dialect := range []Dialect{0, DialectPgx + 1, -1} {
+
+		_, err = NewNamedParameterQueryForDialect("SELECT * FROM table WHERE col1 = :foo", dialect)
+		if(err == nil) {
+			test.Log("Test UnknownDialect: Expected an error for ", dialect)
+			test.Fail()
+		}
+	}
+}
+
+func TestArgIndicationDialects(test *testing.T) {
+
+	expected := map[string]Dialect {
+		":": DialectNamed,
+		"$": DialectPostgres,
+		":N": DialectOracle,
+		"?N": DialectSQLite,
+		"?": DialectMySQL,
+		"": DialectMySQL,
+	}
+
+	for argIndication, dialect := range expected {
+
+		if(dialectOf(argIndication) != dialect) {
+			test.Log("Test '", argIndication, "': Expected ", dialect, ", Actual: ", dialectOf(argIndication))
+			test.Fail()
+		}
+	}
+}
+
+func TestOracleDialectPlaceholders(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	query = NewNamedParameterQuery("UPDATE table SET col1 = :bar WHERE col2 = :foo OR col3 = :foo", ":N")
+	query.SetValue("foo", 1)
+	query.SetValue("bar", "two")
+
+	if(query.GetParsedQuery() != "UPDATE table SET col1 = :1 WHERE col2 = :2 OR col3 = :3") {
+		test.Log("Test OracleQuery: Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+
+	verifyStructParameters("OracleParameters", test, query, []interface{} {
+		"two",
+		1,
+		1,
+	})
+
+	_, err = NewNamedParameterQueryForDialect("SELECT * FROM table WHERE col1 = :1 AND col2 = :foo", DialectOracle)
+	if(err == nil) {
+		test.Log("Test MixedOraclePlaceholders: Expected an error")
+		test.Fail()
+	}
+
+	_, err = NewNamedParameterQueryForDialect("SELECT * FROM table WHERE col1 = :1 AND col2 = :foo", DialectPostgres)
+	if(err != nil) {
+		test.Log("Test ArraySliceWithPostgres: unexpected error: ", err)
+		test.Fail()
+	}
+}
+
+func TestNamedParameters(test *testing.T) {
+
+	var query *NamedParameterQuery
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo", ":")
+	query.SetValue("foo", 1)
+	query.SetValue("bar", "two")
+
+	verifyParameters("NamedParameters", test, query.GetNamedParameters(), []interface{} {
+		sql.Named("foo", 1),
+		sql.Named("bar", "two"),
+	})
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :baz", ":")
+	query.SetValue("bar", nil)
+
+	verifyParameters("UnsetNamedParametersIncluded", test, query.GetNamedParameters(), []interface{} {
+		sql.Named("foo", nil),
+		sql.Named("bar", nil),
+		sql.Named("baz", nil),
+	})
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :baz", ":", WithUnsetParametersOmitted())
+	query.SetValue("bar", nil)
+
+	verifyParameters("UnsetNamedParametersOmitted", test, query.GetNamedParameters(), []interface{} {
+		sql.Named("bar", nil),
+	})
+}
+
+func TestReusedPlaceholders(test *testing.T) {
+
+	var query *NamedParameterQuery
+
+	expected := map[Dialect]string {
+		DialectPostgres: "SELECT * FROM table WHERE col1 = $1 AND col2 = $2 AND col3 = $1 OR col4 = $3",
+		DialectOracle: "SELECT * FROM table WHERE col1 = :1 AND col2 = :2 AND col3 = :1 OR col4 = :3",
+		DialectMySQL: "SELECT * FROM table WHERE col1 = ? AND col2 = ? AND col3 = ? OR col4 = ?",
+	}
+
+	for dialect, expectedQuery := range expected {
+
+		query, _ = NewNamedParameterQueryForDialect("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo OR col4 = :baz", dialect, WithReusedPlaceholders())
+
+		if(query.GetParsedQuery() != expectedQuery) {
+			test.Log("Test '", dialect, "': Expected query: ", expectedQuery, ", Actual: ", query.GetParsedQuery())
+			test.Fail()
+		}
+	}
+
+	query, _ = NewNamedParameterQueryForDialect("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo OR col4 = :baz", DialectPostgres, WithReusedPlaceholders())
+
+	if(query.GetParameterCount() != query.GetDistinctParameterCount()) {
+		test.Log("Test ReusedParameterCount: Expected ", query.GetDistinctParameterCount(), " parameters, Actual: ", query.GetParameterCount())
+		test.Fail()
+	}
+}
+
+func TestReusedPlaceholderValues(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var parameters []interface{}
+	var err error
+
+	query, _ = NewNamedParameterQueryForDialect("SELECT * FROM table WHERE col1 = :userId AND col2 = :other AND col3 = :userId AND col4 = :userId", DialectPostgres, WithReusedPlaceholders())
+
+	query.SetValue("userId", 42)
+
+	// the value fills exactly one slot.
+	verifyStructParameters("ReusedSetValue", test, query, []interface{} {
+		42,
+		nil,
+	})
+
+	if(!query.IsValueSet("userId") || query.IsValueSet("other")) {
+		test.Log("Test ReusedIsValueSet: Expected only 'userId' to be set")
+		test.Fail()
+	}
+
+	parameters, err = query.Bind(map[string]interface{} {
+		"userId": 7,
+		"other": "x",
+	})
+	if(err != nil) {
+		test.Log("Test ReusedBind: unexpected error: ", err)
+		test.Fail()
+	}
+	verifyParameters("ReusedBind", test, parameters, []interface{} {
+		7,
+		"x",
+	})
+
+	if(query.SetValueAt("userId", 1, 3) == nil) {
+		test.Log("Test ReusedSetValueAt: Expected an error for occurrences sharing a placeholder")
+		test.Fail()
+	}
+
+	if(len(query.GetParameterOffsets()["userId"]) != 3) {
+		test.Log("Test ReusedOffsets: Expected 3 offsets, Actual: ", query.GetParameterOffsets()["userId"])
+		test.Fail()
+	}
+}
+
+func TestSQLiteDialect(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	query, err = ParseNamedParameterQuery("SELECT * FROM table WHERE data ?? :key AND col1 = :foo AND col2 = :foo", "?N")
+	if(err != nil) {
+		test.Log("Test SQLiteDialect: unexpected error: ", err)
+		test.FailNow()
+	}
+
+	if(query.GetParsedQuery() != "SELECT * FROM table WHERE data ? ?1 AND col1 = ?2 AND col2 = ?2") {
+		test.Log("Test SQLiteQuery: Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+
+	query.SetValue("key", "k")
+	query.SetValue("foo", 2)
+
+	// duplicate positions are collapsed, so "foo" fills a single slot.
+	verifyStructParameters("SQLiteParameters", test, query, []interface{} {
+		"k",
+		2,
+	})
+
+	_, err = ParseNamedParameterQuery("SELECT * FROM table WHERE col1 = ? AND col2 = :foo", "?N")
+	if(err == nil) {
+		test.Log("Test SQLiteMixedPlaceholders: Expected an error")
+		test.Fail()
+	}
+}
+
+func TestPlaceholderFunc(test *testing.T) {
+
+	var query *NamedParameterQuery
+
+	clickHouse := func(name string, ordinal int) string {
+		return fmt.Sprintf("{p%d:String}", ordinal)
+	}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo", "?", WithPlaceholderFunc(clickHouse))
+
+	if(query.GetParsedQuery() != "SELECT * FROM table WHERE col1 = {p1:String} AND col2 = {p2:String} AND col3 = {p3:String}") {
+		test.Log("Test PlaceholderFunc: Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo", "?", WithPlaceholderFunc(clickHouse), WithReusedPlaceholders())
+
+	if(query.GetParsedQuery() != "SELECT * FROM table WHERE col1 = {p1:String} AND col2 = {p2:String} AND col3 = {p1:String}") {
+		test.Log("Test ReusedPlaceholderFunc: Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+
+	if(query.GetParameterCount() != 2) {
+		test.Log("Test ReusedPlaceholderFuncCount: Expected 2 parameters, Actual: ", query.GetParameterCount())
+		test.Fail()
+	}
+}
+
+func TestOccurrencePlaceholderFunc(test *testing.T) {
+
+	var query *NamedParameterQuery
+
+	numbered := func(name string, occurrence int, ordinal int) string {
+		return fmt.Sprintf(":v%d", ordinal-1)
+	}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo", "?", WithOccurrencePlaceholderFunc(numbered))
+	query.SetValue("foo", 1)
+	query.SetValue("bar", 2)
+
+	if(query.GetParsedQuery() != "SELECT * FROM table WHERE col1 = :v0 AND col2 = :v1 AND col3 = :v2") {
+		test.Log("Test OccurrencePlaceholderFunc: Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+	verifyParameters("OccurrencePlaceholderFuncValues", test, query.GetParsedParameters(), []interface{} {1, 2, 1})
+
+	perName := func(name string, occurrence int, ordinal int) string {
+		return fmt.Sprintf("@%s_%d_%d", name, occurrence, ordinal)
+	}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 IN (:ids) AND col3 = :foo", "?", WithOccurrencePlaceholderFunc(perName), WithPlaceholderOffset(1))
+	query.SetValue("ids", []int{1, 2})
+
+	// expanded elements share the occurrence of their parameter.
+	if(query.GetParsedQuery() != "SELECT * FROM table WHERE col1 = @foo_0_2 AND col2 IN (@ids_0_3, @ids_0_4) AND col3 = @foo_1_5") {
+		test.Log("Test OccurrencePlaceholderFuncExpanded: Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col3 = :foo", "?", WithOccurrencePlaceholderFunc(perName), WithReusedPlaceholders())
+
+	if(query.GetParsedQuery() != "SELECT * FROM table WHERE col1 = @foo_0_1 AND col3 = @foo_1_1" || query.GetParameterCount() != 1) {
+		test.Log("Test ReusedOccurrencePlaceholderFunc: Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+
+	query = NewNamedParameterQuery("INSERT INTO t (a) VALUES (:a)", "?", WithOccurrencePlaceholderFunc(perName))
+
+	if(query.ExpandRows([]map[string]interface{} {{"a": 1}, {"a": 2}}) != nil || query.GetParsedQuery() != "INSERT INTO t (a) VALUES (@a_0_1), (@a_1_2)") {
+		test.Log("Test OccurrencePlaceholderFuncRows: Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+}
+
+func TestPlaceholderOffset(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var next *NamedParameterQuery
+
+	query = NewNamedParameterQuery("col1 = :foo AND col2 = :bar", "$", WithPlaceholderOffset(2))
+	query.SetValue("foo", 1)
+	query.SetValue("bar", 2)
+
+	if(query.GetParsedQuery() != "col1 = $3 AND col2 = $4") {
+		test.Log("Test PlaceholderOffset: Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+
+	verifyStructParameters("PlaceholderOffsetParameters", test, query, []interface{} {
+		1,
+		2,
+	})
+
+	if(query.GetHighestOrdinal() != 4) {
+		test.Log("Test HighestOrdinal: Expected 4, Actual: ", query.GetHighestOrdinal())
+		test.Fail()
+	}
+
+	next = NewNamedParameterQuery("col3 = :baz", "$")
+	next.SetValue("baz", 3)
+	next.SetPlaceholderOffset(query.GetHighestOrdinal())
+
+	if(next.GetParsedQuery() != "col3 = $5") {
+		test.Log("Test SetPlaceholderOffset: Actual: ", next.GetParsedQuery())
+		test.Fail()
+	}
+
+	// values set before changing the offset are kept.
+	verifyStructParameters("SetPlaceholderOffsetParameters", test, next, []interface{} {
+		3,
+	})
+
+	query, _ = NewNamedParameterQueryForDialect("col1 = :foo", DialectSQLServer, WithPlaceholderOffset(1))
+	query.SetValue("foo", 1)
+
+	verifyParameters("PlaceholderOffsetNamedArgs", test, query.GetParsedNamedArgs(), []interface{} {
+		sql.Named("p2", 1),
+	})
+}
+
+/*
+	A dialect defined outside of the package, writing ClickHouse style placeholders.
+*/
+type clickHouseDialect struct{}
+
+func (clickHouseDialect) Placeholder(name string, ordinal int) string {
+	return "{" + name + ":String}"
+}
+
+func (clickHouseDialect) ReusesOrdinals() bool {
+	return true
+}
+
+func (clickHouseDialect) PreservesNames() bool {
+	return true
+}
+
+func TestCustomDialect(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	query, err = NewNamedParameterQueryForDialect("SELECT * FROM table WHERE col1 = :foo AND col2 = ? AND col3 = :foo", clickHouseDialect{})
+	if(err != nil) {
+		test.Log("Test CustomDialect: unexpected error: ", err)
+		test.FailNow()
+	}
+
+	if(query.GetParsedQuery() != "SELECT * FROM table WHERE col1 = {foo:String} AND col2 = ? AND col3 = {foo:String}") {
+		test.Log("Test CustomDialectQuery: Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+
+	query.SetValue("foo", 1)
+
+	verifyParameters("CustomDialectArguments", test, query.GetParsedArguments(), []interface{} {
+		sql.Named("foo", 1),
+	})
+
+	_, err = NewNamedParameterQueryForDialect("SELECT :foo", nil)
+	if(err == nil) {
+		test.Log("Test NilDialect: Expected an error")
+		test.Fail()
+	}
+
+	query, _ = NewNamedParameterQueryForDialect("SELECT :foo", DialectPostgres)
+	query.SetValue("foo", 1)
+
+	verifyParameters("BuiltinDialectArguments", test, query.GetParsedArguments(), []interface{} {
+		1,
+	})
+}
+
+func TestAcceptedArgIndications(test *testing.T) {
+
+	var err error
+
+	for _, argIndication := range []string{"?", "$", ":", ":N", "?N"} {
+
+		_, err = ParseNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo", argIndication)
+		if(err != nil) {
+			test.Log("Test '", argIndication, "': unexpected error: ", err)
+			test.Fail()
+		}
+	}
+
+	for _, argIndication := range []string{"", "PG", "postgres", "$$", "%", "@p"} {
+
+		_, err = ParseNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo", argIndication)
+		if(err == nil) {
+			test.Log("Test '", argIndication, "': Expected an error for an unknown arg indication")
+			test.Fail()
+		}
+
+		_, err = ParseNamedParameterScript("SELECT * FROM table WHERE col1 = :foo", argIndication)
+		if(err == nil) {
+			test.Log("Test '", argIndication, "' script: Expected an error for an unknown arg indication")
+			test.Fail()
+		}
+	}
+}
+
+func TestPgxNamedArgs(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var arguments map[string]interface{}
+
+	query, _ = NewNamedParameterQueryForDialect("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo", DialectPgx)
+	query.SetValue("foo", 1)
+
+	arguments = query.GetPgxNamedArgs()
+
+	if(len(arguments) != 2 || arguments["foo"] != 1 || arguments["bar"] != nil) {
+		test.Log("Test PgxNamedArgs: Actual: ", arguments)
+		test.Fail()
+	}
+
+	query, _ = NewNamedParameterQueryForDialect("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar", DialectPgx, WithUnsetParametersOmitted())
+	query.SetValue("foo", 1)
+
+	arguments = query.GetPgxNamedArgs()
+
+	if(len(arguments) != 1 || arguments["foo"] != 1) {
+		test.Log("Test PgxNamedArgsOmitted: Actual: ", arguments)
+		test.Fail()
+	}
+}
@@ -0,0 +1,100 @@
+package namedParameterQuery
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+/*
+	Errors returned, wrapped with some context, by the functions of npq package,
+	so that callers can tell them apart with errors.Is:
+		if errors.Is(err, namedParameterQuery.ErrNotAStruct) { ... }
+*/
+var (
+	// ErrNotAStruct is returned when a struct, or a non-nil pointer to one, was expected, e.g. by SetValuesFromStruct.
+	ErrNotAStruct = errors.New("parameter is not a struct")
+
+	// ErrUnknownParameter is returned when a parameter name isn't used by the query, e.g. by SetValueAt.
+	ErrUnknownParameter = errors.New("unknown parameter")
+
+	// ErrMissingValue is returned when a parameter of the query has no value, e.g. by Validate or Bind.
+	ErrMissingValue = errors.New("no value set")
+
+	// ErrUnbalancedQuote is wrapped by the *ParseError of a string literal or quoted identifier which is never closed.
+	ErrUnbalancedQuote = errors.New("unbalanced quote")
+)
+
+/*
+	ParseError describes a malformed query, as returned by ParseNamedParameterQuery.
+	It tells where the problem is in the original query text, so that it can be fixed there
+	rather than by reverse-engineering the revised query.
+*/
+type ParseError struct {
+	// The byte offset in the query text at which the problem was found.
+	Offset int
+
+	// The line and column of Offset, both starting at 1. Columns are counted in runes.
+	Line   int
+	Column int
+
+	// A short description of the problem, and of what was expected instead.
+	Message string
+
+	// The kind of problem, such as ErrUnbalancedQuote, if it has one; returned by Unwrap.
+	Err error
+}
+
+/*
+	newParseError creates a ParseError for the given [offset] in [queryText].
+*/
+func newParseError(queryText string, offset int, message string) *ParseError {
+
+	var ret *ParseError
+	var character rune
+	var width int
+
+	ret = &ParseError{
+		Offset:  offset,
+		Line:    1,
+		Column:  1,
+		Message: message,
+	}
+
+	for i := 0; i < offset && i < len(queryText); {
+
+		character, width = utf8.DecodeRuneInString(queryText[i:])
+		i += width
+
+		if character == '\n' {
+			ret.Line++
+			ret.Column = 1
+		} else {
+			ret.Column++
+		}
+	}
+	return ret
+}
+
+/*
+	newQuoteError creates a ParseError wrapping ErrUnbalancedQuote for the given [offset] in [queryText].
+*/
+func newQuoteError(queryText string, offset int, message string) *ParseError {
+
+	var ret *ParseError
+
+	ret = newParseError(queryText, offset, message)
+	ret.Err = ErrUnbalancedQuote
+	return ret
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("%s at line %d, column %d (offset %d)", err.Message, err.Line, err.Column, err.Offset)
+}
+
+/*
+	Unwrap returns the kind of problem, so that errors.Is(err, ErrUnbalancedQuote) works on a *ParseError.
+*/
+func (err *ParseError) Unwrap() error {
+	return err.Err
+}
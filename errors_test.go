@@ -0,0 +1,167 @@
+package namedParameterQuery
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+/*
+	Represents a single test of parse error positions.
+	Parsing the given [Input] must fail with a *ParseError located at [Offset], [Line] and [Column].
+*/
+type ParseErrorTest struct {
+	Name string
+	Input string
+	Offset int
+	Line int
+	Column int
+}
+
+func TestParseErrorPosition(test *testing.T) {
+
+	var parseError *ParseError
+	var isParseError bool
+	var err error
+
+	parseErrorTests := []ParseErrorTest {
+		ParseErrorTest {
+			Input: "SELECT * FROM table WHERE col1 = : b",
+			Offset: 33,
+			Line: 1,
+			Column: 34,
+			Name: "SingleLine",
+		},
+		ParseErrorTest {
+			Input: "SELECT *\nFROM table\nWHERE col1 = :foo\n\tAND col2 = 'abc",
+			Offset: 50,
+			Line: 4,
+			Column: 13,
+			Name: "MultiLine",
+		},
+		ParseErrorTest {
+			Input: "SELECT 'é', $$ body",
+			Offset: 13,
+			Line: 1,
+			Column: 13,
+			Name: "MultibyteColumn",
+		},
+		ParseErrorTest {
+			Input: "SELECT N'it''s :30",
+			Offset: 7,
+			Line: 1,
+			Column: 8,
+			Name: "UnterminatedNationalString",
+		},
+	}
+
+	for _, parseErrorTest := range parseErrorTests {
+
+		_, err = ParseNamedParameterQuery(parseErrorTest.Input, "?")
+		parseError, isParseError = err.(*ParseError)
+
+		if(!isParseError) {
+			test.Log("Test '", parseErrorTest.Name, "': Expected a *ParseError, Actual: ", err)
+			test.Fail()
+			continue
+		}
+
+		if(parseError.Offset != parseErrorTest.Offset || parseError.Line != parseErrorTest.Line || parseError.Column != parseErrorTest.Column) {
+			test.Log("Test '", parseErrorTest.Name, "': Expected offset ", parseErrorTest.Offset, " at ", parseErrorTest.Line, ":", parseErrorTest.Column,
+				", Actual: ", parseError.Offset, " at ", parseError.Line, ":", parseError.Column)
+			test.Fail()
+		}
+
+		if(len(parseError.Message) <= 0) {
+			test.Log("Test '", parseErrorTest.Name, "': Expected a description of the problem")
+			test.Fail()
+		}
+	}
+
+	test.Logf("Run %d parse error position tests", len(parseErrorTests))
+}
+
+func TestErrorKinds(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var parseError *ParseError
+	var err error
+
+	for _, queryText := range []string {
+		"SELECT * FROM table WHERE col1 = 'foo",
+		"SELECT * FROM table WHERE \"col1 = :foo",
+		"SELECT * FROM table WHERE col1 = $$foo",
+	} {
+
+		_, err = ParseNamedParameterQuery(queryText, "$")
+
+		if(!errors.Is(err, ErrUnbalancedQuote) || !errors.As(err, &parseError)) {
+			test.Log("Test UnbalancedQuote: Expected ErrUnbalancedQuote for ", queryText, ", Actual: ", err)
+			test.Fail()
+		}
+	}
+
+	_, err = ParseNamedParameterQuery("SELECT * FROM table WHERE col1 = /* :foo", "$")
+	if(err == nil || errors.Is(err, ErrUnbalancedQuote)) {
+		test.Log("Test UnterminatedComment: Expected a ParseError of no kind, Actual: ", err)
+		test.Fail()
+	}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar", "$")
+
+	for name, structErr := range map[string]error {
+		"NotAStruct": query.SetValuesFromStruct(1),
+		"NilStruct": query.SetValuesFromStruct((*QueryParsingTest)(nil)),
+	} {
+		if(!errors.Is(structErr, ErrNotAStruct)) {
+			test.Log("Test ", name, ": Expected ErrNotAStruct, Actual: ", structErr)
+			test.Fail()
+		}
+	}
+
+	err = query.SetValueAt("baz", 0, 1)
+	if(!errors.Is(err, ErrUnknownParameter)) {
+		test.Log("Test UnknownParameter: Expected ErrUnknownParameter, Actual: ", err)
+		test.Fail()
+	}
+
+	query.SetValue("foo", 1)
+	_, err = query.Bind(map[string]interface{} {"foo": 1})
+
+	if(!errors.Is(query.Validate(), ErrMissingValue) || !errors.Is(err, ErrMissingValue)) {
+		test.Log("Test MissingValue: Expected ErrMissingValue, Actual: ", query.Validate(), err)
+		test.Fail()
+	}
+
+	query = NewNamedParameterQuery("INSERT INTO t (a, b) VALUES (:a, :b)", "$")
+	err = query.ExpandRows([]interface{} {map[string]interface{} {"a": 1}, 2})
+
+	if(!errors.Is(err, ErrMissingValue)) {
+		test.Log("Test MissingRowValue: Expected ErrMissingValue, Actual: ", err)
+		test.Fail()
+	}
+
+	err = query.ExpandRows([]interface{} {2})
+	if(!errors.Is(err, ErrNotAStruct)) {
+		test.Log("Test InvalidRow: Expected ErrNotAStruct, Actual: ", err)
+		test.Fail()
+	}
+}
+
+func TestUnterminatedIdentifierMessage(test *testing.T) {
+
+	var err error
+
+	for queryText, closing := range map[string]string {
+		"SELECT \"col1 FROM table WHERE col2 = :foo": "expected a closing \"",
+		"SELECT `col1 FROM table WHERE col2 = :foo": "expected a closing `",
+	} {
+
+		_, err = ParseNamedParameterQuery(queryText, "?")
+
+		if(err == nil || !strings.Contains(err.Error(), closing)) {
+			test.Log("Test UnterminatedIdentifierMessage: Expected '", closing, "' for ", queryText, ", Actual: ", err)
+			test.Fail()
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package namedParameterQuery
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+)
+
+/*
+	placeholderSpan locates a placeholder written in the revised query.
+*/
+type placeholderSpan struct {
+	// The byte offsets in revisedQuery where the placeholder starts and ends.
+	start int
+	end int
+
+	// The index in parameters of the value of the placeholder.
+	position int
+
+	// The parameter name, as given to the placeholder formatter.
+	name string
+
+	// The occurrence of the parameter name, counting from 0, as given to the placeholder formatter.
+	occurrence int
+}
+
+/*
+	expandsSlices returns true if one of the values set is a slice to expand into several placeholders.
+*/
+func (npq *NamedParameterQuery) expandsSlices() bool {
+
+	if !npq.canExpandSlices() {
+		return false
+	}
+
+	for i, value := range npq.parameters {
+		if npq.set[i] && isExpandable(value) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+	canExpandSlices returns true if slice values are expanded at all, i.e. unless WithoutSliceExpansion was given.
+*/
+func (npq *NamedParameterQuery) canExpandSlices() bool {
+	return !npq.noSliceExpansion
+}
+
+/*
+	expandsByName returns true if the placeholders of expanded elements are told apart by name,
+	because the dialect writes names rather than numbers.
+*/
+func (npq *NamedParameterQuery) expandsByName() bool {
+	return npq.placeholder == nil && npq.sqlDialect.PreservesNames()
+}
+
+/*
+	elementName returns the name of the [index]th element of the expanded slice value of the parameter [name],
+	e.g. "ids_0", under which dialects which preserve names write and bind it.
+*/
+func elementName(name string, index int) string {
+	return fmt.Sprintf("%s_%d", name, index)
+}
+
+/*
+	isExpandable returns true if [value] is a slice or an array whose elements are bound one by one.
+	Byte slices and arrays are not, since they are single binary values, nor are driver.Valuer implementations,
+	such as the array types of PostgreSQL drivers.
+*/
+func isExpandable(value interface{}) bool {
+
+	var reflected reflect.Value
+	var isValuer bool
+
+	reflected = reflect.ValueOf(value)
+
+	if reflected.Kind() != reflect.Slice && reflected.Kind() != reflect.Array {
+		return false
+	}
+
+	if reflected.Type().Elem().Kind() == reflect.Uint8 {
+		return false
+	}
+
+	_, isValuer = value.(driver.Valuer)
+	return !isValuer
+}
+
+/*
+	expandSlices returns the revised query and its parameters with every slice value expanded
+	into one placeholder and one parameter per element, renumbering the placeholders.
+	An empty slice is bound as a single NULL, since "IN ()" is not valid SQL.
+	With dialects which preserve names, the elements of ":ids" are written under the names "ids_0", "ids_1", ...,
+	which GetNamedParameters binds.
+*/
+func (npq *NamedParameterQuery) expandSlices() (string, []interface{}) {
+
+	var query bytes.Buffer
+	var parameters []interface{}
+	var ordinals [][]int
+	var reflected reflect.Value
+	var value interface{}
+	var name string
+	var expanded []bool
+	var last int
+
+	ordinals = make([][]int, len(npq.parameters))
+	expanded = make([]bool, len(npq.parameters))
+
+	for _, span := range npq.placeholders {
+
+		query.WriteString(npq.revisedQuery[last:span.start])
+		last = span.end
+
+		// a reused placeholder is expanded the same way every time.
+		if ordinals[span.position] == nil {
+
+			value = npq.parameters[span.position]
+
+			if !npq.set[span.position] || !isExpandable(value) {
+				parameters = append(parameters, value)
+				ordinals[span.position] = append(ordinals[span.position], len(parameters))
+			} else {
+
+				expanded[span.position] = true
+				reflected = reflect.ValueOf(value)
+
+				for i := 0; i < reflected.Len(); i++ {
+					parameters = append(parameters, normalizeValue(reflected.Index(i).Interface()))
+					ordinals[span.position] = append(ordinals[span.position], len(parameters))
+				}
+
+				if reflected.Len() == 0 {
+					parameters = append(parameters, nil)
+					ordinals[span.position] = append(ordinals[span.position], len(parameters))
+				}
+			}
+		}
+
+		for i, ordinal := range ordinals[span.position] {
+
+			if i > 0 {
+				query.WriteString(", ")
+			}
+
+			name = span.name
+			if expanded[span.position] && npq.expandsByName() {
+				name = elementName(span.name, i)
+			}
+			query.WriteString(npq.formatPlaceholder(name, span.occurrence, ordinal))
+		}
+	}
+
+	query.WriteString(npq.revisedQuery[last:])
+	return query.String(), parameters
+}
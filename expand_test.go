@@ -0,0 +1,224 @@
+package namedParameterQuery
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+)
+
+/*
+	Represents a single test of slice expansion.
+	Once [Values] are set on [Input], the parsed query must be [Expected], with [ExpectedParameters] as parameters.
+*/
+type SliceExpansionTest struct {
+	Name string
+	Input string
+	Values map[string]interface{}
+	Expected string
+	ExpectedParameters []interface{}
+}
+
+func verifySliceExpansion(test *testing.T, expansionTests []SliceExpansionTest, dialect Dialect, options ...Option) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	for _, expansionTest := range expansionTests {
+
+		query, err = NewNamedParameterQueryForDialect(expansionTest.Input, dialect, options...)
+		if(err != nil) {
+			test.Log("Test '", expansionTest.Name, "': unexpected error: ", err)
+			test.Fail()
+			continue
+		}
+
+		query.SetValuesFromMap(expansionTest.Values)
+
+		if(query.GetParsedQuery() != expansionTest.Expected) {
+			test.Log("Test '", expansionTest.Name, "': Expected query: ", expansionTest.Expected, ", Actual: ", query.GetParsedQuery())
+			test.Fail()
+		}
+
+		verifyStructParameters(expansionTest.Name, test, query, expansionTest.ExpectedParameters)
+
+		if(query.GetParameterCount() != len(expansionTest.ExpectedParameters)) {
+			test.Log("Test '", expansionTest.Name, "': Expected ", len(expansionTest.ExpectedParameters), " parameters, Actual: ", query.GetParameterCount())
+			test.Fail()
+		}
+	}
+}
+
+func TestSliceExpansion(test *testing.T) {
+
+	verifySliceExpansion(test, []SliceExpansionTest {
+		SliceExpansionTest {
+			Name: "MixedScalarAndSlice",
+			Input: "SELECT * FROM table WHERE a = :foo AND id IN (:ids) AND b = :bar",
+			Values: map[string]interface{} {
+				"foo": "x",
+				"ids": []int64{1, 2, 3},
+				"bar": "y",
+			},
+			Expected: "SELECT * FROM table WHERE a = $1 AND id IN ($2, $3, $4) AND b = $5",
+			ExpectedParameters: []interface{} {"x", int64(1), int64(2), int64(3), "y"},
+		},
+		SliceExpansionTest {
+			Name: "RepeatedSlice",
+			Input: "SELECT * FROM table WHERE a IN (:ids) OR b IN (:ids)",
+			Values: map[string]interface{} {
+				"ids": []string{"a", "b"},
+			},
+			Expected: "SELECT * FROM table WHERE a IN ($1, $2) OR b IN ($3, $4)",
+			ExpectedParameters: []interface{} {"a", "b", "a", "b"},
+		},
+		SliceExpansionTest {
+			Name: "ArrayAndEmptySlice",
+			Input: "SELECT * FROM table WHERE a IN (:array) AND b IN (:empty)",
+			Values: map[string]interface{} {
+				"array": [2]int{1, 2},
+				"empty": []int{},
+			},
+			Expected: "SELECT * FROM table WHERE a IN ($1, $2) AND b IN ($3)",
+			ExpectedParameters: []interface{} {1, 2, nil},
+		},
+		SliceExpansionTest {
+			Name: "BytesAreNotExpanded",
+			Input: "SELECT * FROM table WHERE a = :data AND b IN (:ids)",
+			Values: map[string]interface{} {
+				"data": [2]byte{'a', 'b'},
+				"ids": []int{1, 2},
+			},
+			Expected: "SELECT * FROM table WHERE a = $1 AND b IN ($2, $3)",
+			ExpectedParameters: []interface{} {[2]byte{'a', 'b'}, 1, 2},
+		},
+	}, DialectPostgres)
+
+	// dialects which preserve names expand slices the same way, into one name per element.
+	verifySliceExpansion(test, []SliceExpansionTest {
+		SliceExpansionTest {
+			Name: "PgxMixedScalarAndSlice",
+			Input: "SELECT * FROM table WHERE a = :foo AND id IN (:ids) AND b = :foo OR c IN (:ids, :empty)",
+			Values: map[string]interface{} {
+				"foo": "x",
+				"ids": []int64{1, 2},
+				"empty": []int{},
+			},
+			Expected: "SELECT * FROM table WHERE a = @foo AND id IN (@ids_0, @ids_1) AND b = @foo OR c IN (@ids_0, @ids_1, @empty_0)",
+			ExpectedParameters: []interface{} {"x", int64(1), int64(2), "x", int64(1), int64(2), nil},
+		},
+	}, DialectPgx)
+
+	verifySliceExpansion(test, []SliceExpansionTest {
+		SliceExpansionTest {
+			Name: "QuestionMarks",
+			Input: "SELECT * FROM table WHERE id IN (:ids) AND a = :foo",
+			Values: map[string]interface{} {
+				"ids": []int{1, 2},
+				"foo": "x",
+			},
+			Expected: "SELECT * FROM table WHERE id IN (?, ?) AND a = ?",
+			ExpectedParameters: []interface{} {1, 2, "x"},
+		},
+	}, DialectMySQL)
+
+	verifySliceExpansion(test, []SliceExpansionTest {
+		SliceExpansionTest {
+			Name: "ReusedPlaceholders",
+			Input: "SELECT * FROM table WHERE a IN (:ids) AND b = :foo OR c IN (:ids)",
+			Values: map[string]interface{} {
+				"ids": []int{1, 2},
+				"foo": "x",
+			},
+			Expected: "SELECT * FROM table WHERE a IN ($1, $2) AND b = $3 OR c IN ($1, $2)",
+			ExpectedParameters: []interface{} {1, 2, "x"},
+		},
+	}, DialectPostgres, WithReusedPlaceholders())
+
+	verifySliceExpansion(test, []SliceExpansionTest {
+		SliceExpansionTest {
+			Name: "PlaceholderOffset",
+			Input: "id IN (:ids) AND a = :foo",
+			Values: map[string]interface{} {
+				"ids": []int{1, 2},
+				"foo": "x",
+			},
+			Expected: "id IN ($3, $4) AND a = $5",
+			ExpectedParameters: []interface{} {1, 2, "x"},
+		},
+	}, DialectPostgres, WithPlaceholderOffset(2))
+
+	verifySliceExpansion(test, []SliceExpansionTest {
+		SliceExpansionTest {
+			Name: "WithoutSliceExpansion",
+			Input: "SELECT * FROM table WHERE id = ANY(:ids)",
+			Values: map[string]interface{} {
+				"ids": [2]int{1, 2},
+			},
+			Expected: "SELECT * FROM table WHERE id = ANY($1)",
+			ExpectedParameters: []interface{} {[2]int{1, 2}},
+		},
+	}, DialectPostgres, WithoutSliceExpansion())
+}
+
+func TestByteSliceIsNotExpanded(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var data []byte
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE a = :data", "$")
+	query.SetValue("data", []byte("ab"))
+
+	data, _ = query.GetParsedParameters()[0].([]byte)
+
+	if(query.GetParsedQuery() != "SELECT * FROM table WHERE a = $1" || string(data) != "ab") {
+		test.Log("Test ByteSliceIsNotExpanded: Actual: ", query.GetParsedQuery(), query.GetParsedParameters())
+		test.Fail()
+	}
+}
+
+func TestNamedSliceExpansion(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var arguments map[string]interface{}
+
+	query, _ = NewNamedParameterQueryForDialect("SELECT * FROM table WHERE a = :foo AND id IN (:ids)", DialectPostgres)
+	query.SetValue("foo", "x")
+	query.SetValue("ids", []int{1, 2})
+
+	if(query.GetParsedQuery() != "SELECT * FROM table WHERE a = $1 AND id IN ($2, $3)") {
+		test.Log("Test PostgresSliceExpansion: Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+
+	query, _ = NewNamedParameterQueryForDialect("SELECT * FROM table WHERE a = :foo AND id IN (:ids)", DialectPgx)
+	query.SetValue("foo", "x")
+	query.SetValue("ids", []int{1, 2})
+
+	arguments = query.GetPgxNamedArgs()
+
+	if(query.GetParsedQuery() != "SELECT * FROM table WHERE a = @foo AND id IN (@ids_0, @ids_1)" ||
+		len(arguments) != 3 || arguments["foo"] != "x" || arguments["ids_0"] != 1 || arguments["ids_1"] != 2) {
+		test.Log("Test PgxSliceExpansion: Actual: ", query.GetParsedQuery(), arguments)
+		test.Fail()
+	}
+
+	query, _ = NewNamedParameterQueryForDialect("SELECT * FROM table WHERE id IN (:ids)", DialectNamed)
+	query.SetValue("ids", []string{"a", "b"})
+
+	if(query.GetParsedQuery() != "SELECT * FROM table WHERE id IN (:ids_0, :ids_1)" || len(query.GetParsedArguments()) != 2 ||
+		query.GetParsedArguments()[1] != sql.Named("ids_1", "b")) {
+		test.Log("Test NamedSliceExpansion: Actual: ", query.GetParsedQuery(), query.GetParsedArguments())
+		test.Fail()
+	}
+
+	// without expansion, the slice is bound as it is, under its own name.
+	query, _ = NewNamedParameterQueryForDialect("SELECT * FROM table WHERE id = ANY(:ids)", DialectPgx, WithoutSliceExpansion())
+	query.SetValue("ids", []int{1, 2})
+
+	arguments = query.GetPgxNamedArgs()
+
+	if(query.GetParsedQuery() != "SELECT * FROM table WHERE id = ANY(@ids)" || len(arguments) != 1 || fmt.Sprint(arguments["ids"]) != "[1 2]") {
+		test.Log("Test PgxWithoutSliceExpansion: Actual: ", query.GetParsedQuery(), arguments)
+		test.Fail()
+	}
+}
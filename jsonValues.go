@@ -0,0 +1,170 @@
+package namedParameterQuery
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+	SetValuesFromJSON decodes the JSON object [data] and sets its members as SetValuesFromMap does.
+	JSON null is a SQL NULL, arrays are []interface{}, which are expanded as slices,
+	and nested objects are maps, which fill dotted parameter names such as ":user.id".
+	Numbers keep their precision: those written without a fraction or an exponent which fit an int64 are int64,
+	and all others float64. Members which aren't parameters of the query are ignored.
+	If [data] isn't a valid JSON object, an error is returned and no value is set.
+*/
+func (npq *NamedParameterQuery) SetValuesFromJSON(data []byte) error {
+
+	var parameters map[string]interface{}
+	var err error
+
+	parameters, err = decodeJSONObject(data)
+	if err != nil {
+		return fmt.Errorf("unable to add query values from JSON: %w", err)
+	}
+
+	npq.SetValuesFromMap(parameters)
+	return nil
+}
+
+/*
+	SetValuesFromJSONStrict works like SetValuesFromJSON, but returns an error wrapping ErrUnknownParameter,
+	and sets no value, if a member of [data] isn't a parameter of the query.
+	A nested object is known if it fills dotted parameter names, and each of its members must be known too.
+*/
+func (npq *NamedParameterQuery) SetValuesFromJSONStrict(data []byte) error {
+
+	var parameters map[string]interface{}
+	var err error
+
+	parameters, err = decodeJSONObject(data)
+	if err != nil {
+		return fmt.Errorf("unable to add query values from JSON: %w", err)
+	}
+
+	err = npq.checkKnownMembers(parameters, "")
+	if err != nil {
+		return fmt.Errorf("unable to add query values from JSON: %w", err)
+	}
+
+	npq.SetValuesFromMap(parameters)
+	return nil
+}
+
+/*
+	checkKnownMembers returns an error if a member of [values], whose names are prefixed with [prefix],
+	is neither a parameter of the query nor an object filling dotted parameter names.
+*/
+func (npq *NamedParameterQuery) checkKnownMembers(values map[string]interface{}, prefix string) error {
+
+	var nested map[string]interface{}
+	var name string
+	var isObject bool
+	var err error
+
+	for key, value := range values {
+
+		name = npq.normalizeName(prefix + key)
+
+		if npq.findParameter(name) != nil {
+			continue
+		}
+
+		nested, isObject = value.(map[string]interface{})
+		if !isObject || !npq.hasParameterUnder(name+".") {
+			return fmt.Errorf("%w '%s'", ErrUnknownParameter, prefix+key)
+		}
+
+		err = npq.checkKnownMembers(nested, prefix+key+".")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+	hasParameterUnder returns true if the name of a parameter of the query starts with the already normalized [prefix].
+*/
+func (npq *NamedParameterQuery) hasParameterUnder(prefix string) bool {
+
+	for _, parameter := range npq.namedParameters {
+		if strings.HasPrefix(parameter.name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+	decodeJSONObject decodes the JSON object [data], with its numbers converted by convertJSONNumbers.
+*/
+func decodeJSONObject(data []byte) (map[string]interface{}, error) {
+
+	var decoder *json.Decoder
+	var parameters map[string]interface{}
+	var err error
+
+	// "null" would otherwise decode into a nil map, as if it were an empty object.
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("{")) {
+		return nil, errors.New("the JSON value is not an object")
+	}
+
+	decoder = json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	err = decoder.Decode(&parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = decoder.Token()
+	if err != io.EOF {
+		return nil, errors.New("unexpected data after the JSON object")
+	}
+
+	for key, value := range parameters {
+		parameters[key] = convertJSONNumbers(value)
+	}
+	return parameters, nil
+}
+
+/*
+	convertJSONNumbers returns [value] with every json.Number in it, nested ones included,
+	turned into an int64 if it is an integer which fits one, or else a float64.
+*/
+func convertJSONNumbers(value interface{}) interface{} {
+
+	var integer int64
+	var float float64
+	var err error
+
+	switch typed := value.(type) {
+	case json.Number:
+
+		integer, err = typed.Int64()
+		if err == nil {
+			return integer
+		}
+
+		float, _ = typed.Float64()
+		return float
+
+	case map[string]interface{}:
+
+		for key, member := range typed {
+			typed[key] = convertJSONNumbers(member)
+		}
+
+	case []interface{}:
+
+		for i, element := range typed {
+			typed[i] = convertJSONNumbers(element)
+		}
+	}
+	return value
+}
@@ -0,0 +1,106 @@
+package namedParameterQuery
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestSetValuesFromJSON(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var syntaxError *json.SyntaxError
+	var err error
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :name AND col2 = :age AND col3 = :user.id AND col4 = :missing AND col5 IN(:ids) AND col6 = :none AND col7 = :price AND col8 = :big", "?")
+
+	err = query.SetValuesFromJSON([]byte(`{"name": "Alice", "age": 30, "user": {"id": 9007199254740993}, "ids": [1, 2.5], "none": null, "price": 1e2, "big": 18446744073709551616, "unused": true}`))
+	if(err != nil) {
+		test.Log("Test JSONValues: unexpected error: ", err)
+		test.Fail()
+	}
+
+	// integers keep their precision, beyond that of a float64 too.
+	verifyParameters("JSONValues", test, query.GetParsedParameters(), []interface{} {
+		"Alice",
+		int64(30),
+		int64(9007199254740993),
+		nil,
+		int64(1),
+		float64(2.5),
+		nil,
+		float64(100),
+		float64(18446744073709551616),
+	})
+
+	if(query.IsValueSet("missing") || !query.IsValueSet("none")) {
+		test.Log("Test JSONNullIsSet: Expected only members of the object to be set")
+		test.Fail()
+	}
+
+	query.Reset()
+
+	for _, invalid := range []string{`{"name": `, `["Alice"]`, `"Alice"`, `null`, ` null `, `[]`, `1`, ``, `{"name": "Alice"} {}`, `{"name": "Alice"} x`} {
+
+		err = query.SetValuesFromJSON([]byte(invalid))
+		if(err == nil) {
+			test.Log("Test InvalidJSON: Expected an error for ", invalid)
+			test.Fail()
+		}
+	}
+
+	if(len(query.MissingParameters()) != 8) {
+		test.Log("Test InvalidJSONSetsNothing: Actual set: ", query.String())
+		test.Fail()
+	}
+
+	// decoding errors are wrapped, not flattened.
+	err = query.SetValuesFromJSON([]byte(`{"name": }`))
+	if(!errors.As(err, &syntaxError)) {
+		test.Log("Test JSONSyntaxError: Expected a *json.SyntaxError, Actual: ", err)
+		test.Fail()
+	}
+
+	err = query.SetValuesFromJSONStrict([]byte(`{"name": }`))
+	if(!errors.As(err, &syntaxError)) {
+		test.Log("Test JSONStrictSyntaxError: Expected a *json.SyntaxError, Actual: ", err)
+		test.Fail()
+	}
+}
+
+func TestSetValuesFromJSONStrict(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :name AND col2 = :user.id AND col3 = :tags", "?")
+
+	err = query.SetValuesFromJSONStrict([]byte(`{"name": "Alice", "user": {"id": 7}, "tags": {"any": "object"}}`))
+	if(err != nil) {
+		test.Log("Test JSONStrict: unexpected error: ", err)
+		test.Fail()
+	}
+	verifyParameters("JSONStrict", test, query.GetParsedParameters()[:2], []interface{} {"Alice", int64(7)})
+
+	for _, unknown := range []string{`{"name": "Bob", "age": 30}`, `{"name": "Bob", "user": {"id": 8, "age": 30}}`, `{"name": "Bob", "user": 8}`} {
+
+		err = query.SetValuesFromJSONStrict([]byte(unknown))
+		if(!errors.Is(err, ErrUnknownParameter)) {
+			test.Log("Test JSONStrictUnknown: Expected ErrUnknownParameter for ", unknown, ", Actual: ", err)
+			test.Fail()
+		}
+	}
+
+	if(query.GetParsedParameters()[0] != "Alice") {
+		test.Log("Test JSONStrictSetsNothing: Actual: ", query.String())
+		test.Fail()
+	}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :userName AND col2 = :User.ID", "?", WithCaseInsensitiveNames())
+
+	err = query.SetValuesFromJSONStrict([]byte(`{"USERNAME": "Alice", "user": {"id": 7}}`))
+	if(err != nil || query.Validate() != nil) {
+		test.Log("Test JSONStrictCaseInsensitive: unexpected error: ", err, query.Validate())
+		test.Fail()
+	}
+}
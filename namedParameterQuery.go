@@ -47,14 +47,27 @@
 		query.SetValuesFromStruct(parameter)
 		connection, _ := sql.Open("mysql", "user:pass@tcp(localhost:3306)/db")
 		connection.QueryRow(query.GetParsedQuery(), (query.GetParsedParameters())...)
+	PostgreSQL JSON operators such as "->", "->>", "#>" and "#>>" are copied as they are.
+	So are the jsonb "?", "?|" and "?&" operators, but a driver given "?" placeholders can't tell them
+	from its own placeholders; queries using them are best written for the "$" output:
+		query := NewNamedParameterQuery("SELECT * FROM table WHERE data ? :key", "$")
+	With the "?" output, they must be written "??", "??|" and "??&", which ParseNamedParameterQuery enforces.
+	A parameter name ends at the first rune which can't be part of it, so parameters need no space between them:
+	"IN(:a,:b)" has the parameters "a" and "b", and so has ":a:b", which is two adjacent parameters.
+	A doubled prefix is not a parameter though: ":a::int" is the parameter "a" followed by a PostgreSQL cast.
 */
 package namedParameterQuery
 
 import (
 	"bytes"
+	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
+	"net/url"
 	"reflect"
+	"strings"
+	"sync"
 	"unicode"
 	"unicode/utf8"
 )
@@ -65,155 +78,2041 @@ import (
 	instead use NewNamedParameterQuery
 */
 type NamedParameterQuery struct {
-	// A map of parameter names as keys, with value as a slice of positional indices which match
-	// that parameter.
-	positions map[string][]int
+	// The distinct parameters of the query, in order of first appearance,
+	// each with the positional indices which match that parameter.
+	namedParameters []namedParameter
 
 	// Contains all positional parameters, in order, ready to be used in the positional query.
 	parameters []interface{}
 
+	// Whether each positional parameter was given a value, so that binding nil can be told from not binding at all.
+	set []bool
+
+	// The name of the parameter of each positional parameter, in order.
+	positionalNames []string
+
 	// The query containing named parameters, as passed in by NewNamedParameterQuery
 	originalQuery string
 
-	// The query containing positional parameters, as generated by setQuery
-	revisedQuery string
+	// The query containing positional parameters, as generated by setQuery
+	revisedQuery string
+
+	// Where each placeholder was written in revisedQuery, in order.
+	placeholders []placeholderSpan
+
+	// The byte offsets in originalQuery of the semicolons which end a statement,
+	// i.e. those outside of quotes and comments.
+	semicolons []int
+
+	// The placeholders written in the revised query.
+	sqlDialect SQLDialect
+
+	// The built-in dialect, which tells how placeholders already in the query are handled,
+	// or zero if sqlDialect is a custom one.
+	dialect Dialect
+
+	// Whether [bracketed] identifiers are copied verbatim, as SQL Server quotes them.
+	bracketIdentifiers bool
+
+	// The characters which start a named parameter in the original query.
+	prefixes []rune
+
+	// Whether parameter names may start with a digit.
+	numericNames bool
+
+	// When set, tells whether a rune may be part of a parameter name, instead of the default rules.
+	nameRune func(rune) bool
+
+	// Whether "{name}" is a parameter too.
+	braceParameters bool
+
+	// Whether parameter names are matched regardless of their case.
+	caseInsensitive bool
+
+	// Whether "#" starts a line comment, as in MySQL.
+	hashComments bool
+
+	// Whether every occurrence of a parameter is written with the same numbered placeholder, whatever the dialect.
+	reusePlaceholders bool
+
+	// Whether a doubled prefix ("::") is written as a single literal prefix, rather than copied as it is.
+	doubledPrefixEscape bool
+
+	// The number added to the ordinals of numbered placeholders, so that they start at placeholderOffset+1.
+	placeholderOffset int
+
+	// Whether GetNamedParameters leaves out the parameters which were never given a value.
+	omitUnsetParameters bool
+
+	// Whether slice values are bound as they are, rather than expanded into one placeholder per element.
+	noSliceExpansion bool
+
+	// When set, writes the placeholders instead of the dialect.
+	placeholder func(name string, occurrence int, ordinal int) string
+
+	// The struct tag read by SetValuesFromStruct before sqlParameterName, if any.
+	structTag string
+
+	// When set, names the untagged struct fields given to SetValuesFromStruct, instead of their field names.
+	fieldNameMapper func(string) string
+
+	// Whether runs of whitespace outside of literals and comments are written as a single space.
+	collapseWhitespace bool
+
+	// The value of the positional parameters which were never given one, and whether SetDefault gave it.
+	defaultValue interface{}
+	hasDefault bool
+
+	// Whether SetValuesFromURLValues binds an empty value as NULL, rather than as an empty string.
+	emptyURLValuesAsNull bool
+
+	// The first error returned to a chained setter such as WithValuesFromStruct, reported by Err.
+	err error
+
+	// Whether setQuery records literals, so that ExpandRows can find the VALUES tuple outside of them.
+	recordLiterals bool
+
+	// When recordLiterals is set, where string literals, quoted identifiers and comments were written in revisedQuery, in order.
+	literals []textSpan
+
+	// Whether setQuery only records the distinct parameter names, for ExtractParameterNames,
+	// leaving out their values, offsets and placeholders, and the revised query.
+	namesOnly bool
+}
+
+/*
+	namedParameter describes one of the distinct named parameters of a query.
+*/
+type namedParameter struct {
+	// The name of the parameter, folded to lower case when names are case-insensitive.
+	name string
+
+	// The positional indices which match that parameter.
+	positions []int
+
+	// The rune offsets in originalQuery of each occurrence of that parameter, prefix included.
+	offsets []int
+
+	// When names are case-insensitive, the distinct spellings of the name found in the query.
+	spellings []string
+}
+
+/*
+	Option configures optional parsing behaviour of a NamedParameterQuery.
+	Options are given to NewNamedParameterQuery after the query text and arg indication.
+*/
+type Option func(*NamedParameterQuery)
+
+/*
+	WithBracketIdentifiers makes the parser treat "[" ... "]" spans as quoted identifiers, the way SQL Server does.
+	Their content is copied verbatim, so that e.g. "[Order:Date]" is not mistaken for a parameter.
+	A doubled "]]" inside the span is a literal right bracket.
+	It is off by default, since brackets are array subscripts in PostgreSQL.
+*/
+func WithBracketIdentifiers() Option {
+	return func(npq *NamedParameterQuery) {
+		npq.bracketIdentifiers = true
+	}
+}
+
+/*
+	WithParameterPrefixes sets the characters which start a named parameter in the query text,
+	e.g. WithParameterPrefixes(':', '@') accepts both ":foo" and "@foo" as the parameter "foo",
+	and WithParameterPrefixes('@') reads SQL Server style "@foo" parameters only.
+	A doubled prefix is never a parameter, so that SQL Server system variables such as "@@ROWCOUNT" are left untouched.
+	With the "$" prefix, both "$name" and "${name}" are parameters, while PostgreSQL positional parameters ("$1")
+	and dollar-quoted strings ("$$ ... $$", "$tag$ ... $tag$") are not.
+	By default, only ":" starts a parameter.
+*/
+func WithParameterPrefixes(prefixes ...rune) Option {
+	return func(npq *NamedParameterQuery) {
+		npq.prefixes = prefixes
+	}
+}
+
+/*
+	WithNumericParameterNames allows parameter names to start with a digit, e.g. ":1".
+	By default, a name must start with a letter or an underscore,
+	so that PostgreSQL array slices such as "arr[1:3]" are left untouched.
+*/
+func WithNumericParameterNames() Option {
+	return func(npq *NamedParameterQuery) {
+		npq.numericNames = true
+	}
+}
+
+/*
+	WithParameterNameRunes makes [isNameRune] decide which runes may be part of a parameter name,
+	instead of letters, digits and underscores, e.g. to allow dashes or dots anywhere in names.
+	A name still cannot start with a digit unless WithNumericParameterNames is given.
+	A nil [isNameRune] keeps the default rules.
+*/
+func WithParameterNameRunes(isNameRune func(rune) bool) Option {
+	return func(npq *NamedParameterQuery) {
+		npq.nameRune = isNameRune
+	}
+}
+
+/*
+	WithBraceParameters makes "{name}" template-style placeholders parameters too, e.g. "WHERE id = {userId}".
+	"{{" and "}}" are then written for literal braces. Braces inside string literals are never parameters,
+	so that JSON literals such as '{"a":1}' are left untouched.
+*/
+func WithBraceParameters() Option {
+	return func(npq *NamedParameterQuery) {
+		npq.braceParameters = true
+	}
+}
+
+/*
+	WithCaseInsensitiveNames makes parameter names case-insensitive: ":UserId", ":userId" and ":userid"
+	are then the same parameter, which SetValue("USERID", ...) sets too. Names are folded to lower case,
+	which is how GetParameterNames returns them; GetParameterSpellings gives the spellings used in the query.
+*/
+func WithCaseInsensitiveNames() Option {
+	return func(npq *NamedParameterQuery) {
+		npq.caseInsensitive = true
+	}
+}
+
+/*
+	WithHashComments makes "#" start a line comment, the way MySQL does, so that e.g. "# at 10:30" is copied verbatim.
+	A "#" inside a string literal or a quoted identifier never starts a comment.
+	It is off by default, since "#" is the bitwise XOR operator in PostgreSQL.
+*/
+func WithHashComments() Option {
+	return func(npq *NamedParameterQuery) {
+		npq.hashComments = true
+	}
+}
+
+/*
+	WithReusedPlaceholders makes every occurrence of a parameter use the same numbered placeholder, e.g.
+	"WHERE a = :foo OR b = :foo" becomes "WHERE a = $1 OR b = $1" with DialectPostgres, so that the value is bound once:
+	GetParsedParameters then has one value per distinct parameter, in order of first appearance.
+	It applies to DialectPostgres, DialectOracle and custom SQLDialects, since DialectSQLServer and DialectSQLite
+	always reuse placeholders, and "?" or ":name" placeholders have no number to reuse.
+	Beware that Oracle only honours reused numbers in PL/SQL blocks; SQL statements are bound by placeholder position.
+*/
+func WithReusedPlaceholders() Option {
+	return func(npq *NamedParameterQuery) {
+		npq.reusePlaceholders = true
+	}
+}
+
+/*
+	WithDoubledPrefixEscape makes a doubled prefix an escape for a literal one: "::foo" is then written ":foo",
+	without starting a parameter, like "\:foo" always is. This suits queries with JSON paths or time literals
+	outside quotes, but not PostgreSQL "::" casts, which would then lose a colon.
+*/
+func WithDoubledPrefixEscape() Option {
+	return func(npq *NamedParameterQuery) {
+		npq.doubledPrefixEscape = true
+	}
+}
+
+/*
+	WithStructTag makes SetValuesFromStruct name fields after their [key] tag, such as the "db" tag of sqlx models,
+	so that they needn't be tagged twice. Fields without that tag, or with an empty one, are named as usual:
+	after their sqlParameterName tag, or else their field name. The tag is read as a sqlParameterName one would be,
+	options such as ",omitempty" and "-" included.
+*/
+func WithStructTag(key string) Option {
+	return func(npq *NamedParameterQuery) {
+		npq.structTag = key
+	}
+}
+
+/*
+	WithFieldNameMapper makes SetValuesFromStruct name untagged fields [mapper](fieldName) rather than their field name,
+	and prefix the fields of untagged nested structs likewise. Tagged fields keep the name of their tag.
+	A [mapper] returning an empty name keeps the field name.
+*/
+func WithFieldNameMapper(mapper func(string) string) Option {
+	return func(npq *NamedParameterQuery) {
+		npq.fieldNameMapper = mapper
+	}
+}
+
+/*
+	WithSnakeCaseFields makes SetValuesFromStruct name untagged fields in snake_case, e.g. ":created_at" for CreatedAt.
+	See SnakeCase and WithFieldNameMapper.
+*/
+func WithSnakeCaseFields() Option {
+	return WithFieldNameMapper(SnakeCase)
+}
+
+/*
+	WithCollapsedWhitespace makes the revised query keep a single space of every run of whitespace,
+	such as the newlines and indentation of a query written over several lines, and none at its start and end.
+	String literals, quoted identifiers and comments are copied as they are, and a line comment still ends with a newline.
+*/
+func WithCollapsedWhitespace() Option {
+	return func(npq *NamedParameterQuery) {
+		npq.collapseWhitespace = true
+	}
+}
+
+/*
+	WithPlaceholderOffset makes numbered placeholders start at [offset]+1, e.g. "$3" for an [offset] of 2,
+	so that the query can be appended to a SQL fragment which already uses "$1" and "$2".
+	GetParsedParameters still only has the values of npq query's own parameters.
+	SetPlaceholderOffset changes the offset of a query which is already parsed.
+*/
+func WithPlaceholderOffset(offset int) Option {
+	return func(npq *NamedParameterQuery) {
+		npq.placeholderOffset = offset
+	}
+}
+
+/*
+	WithUnsetParametersOmitted makes GetNamedParameters leave out the parameters which were never given a value,
+	e.g. so that the driver reports them, or so that the database uses the defaults of a stored procedure.
+	By default, they are included with a nil value, i.e. bound as NULL.
+*/
+func WithUnsetParametersOmitted() Option {
+	return func(npq *NamedParameterQuery) {
+		npq.omitUnsetParameters = true
+	}
+}
+
+/*
+	WithoutSliceExpansion makes slice values bound as they are, e.g. for drivers which bind Go slices
+	as SQL arrays, rather than expanded into one placeholder per element for IN lists.
+*/
+func WithoutSliceExpansion() Option {
+	return func(npq *NamedParameterQuery) {
+		npq.noSliceExpansion = true
+	}
+}
+
+/*
+	WithEmptyURLValuesAsNull makes SetValuesFromURLValues bind a key given with an empty value, e.g. "name=" in "?name=",
+	as a SQL NULL rather than as an empty string.
+*/
+func WithEmptyURLValuesAsNull() Option {
+	return func(npq *NamedParameterQuery) {
+		npq.emptyURLValuesAsNull = true
+	}
+}
+
+/*
+	WithPlaceholderFunc makes [placeholder] write the placeholder of every parameter occurrence in the revised query,
+	instead of the dialect, e.g. for ClickHouse style "{p1:String}" placeholders.
+	It is given the parameter name and the 1-based ordinal of its value in GetParsedParameters,
+	plus the offset given to WithPlaceholderOffset.
+	With WithReusedPlaceholders, every occurrence of a name is given the ordinal of its first occurrence,
+	and the value is bound once; otherwise each occurrence has its own ordinal.
+	The dialect still tells how "?" or "$1" placeholders already in the query are handled.
+*/
+func WithPlaceholderFunc(placeholder func(name string, ordinal int) string) Option {
+	return func(npq *NamedParameterQuery) {
+		npq.placeholder = func(name string, occurrence int, ordinal int) string {
+			return placeholder(name, ordinal)
+		}
+	}
+}
+
+/*
+	WithOccurrencePlaceholderFunc works like WithPlaceholderFunc, but [placeholder] is also given the [occurrence]
+	of the parameter name it writes, counting from 0 in the order they appear in the query,
+	so that every occurrence may be told apart, e.g. ":foo_0" and ":foo_1":
+		WithOccurrencePlaceholderFunc(func(name string, occurrence int, ordinal int) string {
+			return fmt.Sprintf(":%s_%d", name, occurrence)
+		})
+	The elements of an expanded slice share the occurrence of their parameter, and are given their own ordinals.
+*/
+func WithOccurrencePlaceholderFunc(placeholder func(name string, occurrence int, ordinal int) string) Option {
+	return func(npq *NamedParameterQuery) {
+		npq.placeholder = placeholder
+	}
+}
+
+/*
+	NewNamedParameterQuery creates a new named parameter query using the given [queryText] as a SQL query which
+	contains named parameters. Named parameters are identified by starting with a ":"
+	e.g., ":name" refers to the parameter "name", and ":foo" refers to the parameter "foo".
+	Names are made of letters, digits and underscores, and cannot start with a digit.
+	Like quoted strings (SQL Server N'...' national strings included) and identifiers, "--" line comments and C-style block comments are copied as they are, without looking for parameters.
+	A prefix preceded by a backslash is escaped: "\:name" is written as the literal text ":name".
+	Except for their names, named parameters follow all the same rules as positional parameters;
+	they cannot be inside quoted strings, and cannot inject statements into a query. They can only
+	be used to insert values.
+	Any given [options] are applied before the query is parsed.
+	Malformed queries (e.g. with a ":" which isn't followed by a name) are parsed as well as possible;
+	use ParseNamedParameterQuery to have them reported as errors instead.
+	The placeholders written are given by the [argIndication] string, which is kept for compatibility:
+	":" is DialectNamed, "$" DialectPostgres, ":N" DialectOracle, "?N" DialectSQLite, and anything else DialectMySQL.
+
+	Deprecated: use NewNamedParameterQueryForDialect, which takes a typed Dialect rather than an argIndication string,
+	and rejects unknown dialects and malformed queries with an error.
+*/
+func NewNamedParameterQuery(queryText string, argIndication string, options ...Option) *NamedParameterQuery {
+
+	var ret *NamedParameterQuery
+
+	ret = newNamedParameterQuery(dialectOf(argIndication), options)
+
+	// malformed queries are parsed as well as possible, since there is no way to report the error here.
+	ret.setQuery(queryText)
+
+	return ret
+}
+
+/*
+	ParseNamedParameterQuery works like NewNamedParameterQuery, but returns a *ParseError
+	instead of a best-effort query when the given [queryText] is malformed
+	(e.g. contains an unterminated string literal, or a ":" which isn't followed by a parameter name).
+	Queries which already contain "?" positional placeholders are rejected too when the output uses "?",
+	since the positions of named parameters would then be wrong.
+	In that case, a literal "?" (such as the PostgreSQL jsonb operators "?", "?|" and "?&") is written "??".
+	With other outputs, "?" is never touched.
+	Likewise, queries which already contain "$1" style placeholders are rejected when the output uses "$",
+	since their numbers would collide with the generated ones.
+	A parameter prefix which collides with the output placeholders (such as "$" with the "$" output) is an error as well.
+	Unlike NewNamedParameterQuery, an [argIndication] other than "?", "$", ":", ":N" and "?N" is an error,
+	rather than silently meaning "?".
+	NewNamedParameterQueryForDialect works the same, but takes a Dialect instead.
+*/
+func ParseNamedParameterQuery(queryText string, argIndication string, options ...Option) (*NamedParameterQuery, error) {
+
+	var dialect Dialect
+	var err error
+
+	dialect, err = parseArgIndication(argIndication)
+	if err != nil {
+		return nil, err
+	}
+	return parseNamedParameterQuery(queryText, dialect, options)
+}
+
+/*
+	parseNamedParameterQuery parses [queryText] for the given [dialect], returning the first error found.
+*/
+func parseNamedParameterQuery(queryText string, dialect SQLDialect, options []Option) (*NamedParameterQuery, error) {
+
+	var ret *NamedParameterQuery
+	var err error
+
+	ret = newNamedParameterQuery(dialect, options)
+
+	err = ret.checkPrefixes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = ret.setQuery(queryText)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+/*
+	ExtractParameterNames returns the distinct parameter names of the given [queryText], in order of first appearance,
+	as GetParameterNames would for a query made by NewNamedParameterQuery with the same [argIndication] and [options].
+	Parameters are found by the same rules, skipping quoted strings, identifiers and comments.
+	It is meant for introspection, e.g. to ask a caller for exactly the values a query needs.
+	Only the names are recorded while parsing: no values, offsets or placeholders are kept, and no revised query is built.
+*/
+func ExtractParameterNames(queryText string, argIndication string, options ...Option) []string {
+
+	var query *NamedParameterQuery
+
+	query = newNamedParameterQuery(dialectOf(argIndication), options)
+	query.namesOnly = true
+	query.setQuery(queryText)
+
+	return query.GetParameterNames()
+}
+
+/*
+	checkPrefixes returns an error if one of the parameter prefixes would be mistaken for
+	the positional placeholders written in the revised query, e.g. "$name" parameters with "$1" placeholders.
+*/
+func (npq *NamedParameterQuery) checkPrefixes() error {
+
+	for _, prefix := range npq.prefixes {
+
+		if (prefix == '$' && npq.dialect == DialectPostgres) || (prefix == '?' && npq.usesQuestionMarks()) {
+			return fmt.Errorf("parameter prefix '%c' collides with the positional placeholders of the %s dialect", prefix, npq.dialect)
+		}
+	}
+	return nil
+}
+
+/*
+	newNamedParameterQuery creates an empty query for the given [dialect], with [options] applied.
+*/
+func newNamedParameterQuery(dialect SQLDialect, options []Option) *NamedParameterQuery {
+
+	var ret *NamedParameterQuery
+
+	ret = new(NamedParameterQuery)
+	ret.sqlDialect = dialect
+	ret.dialect, _ = dialect.(Dialect)
+	ret.prefixes = []rune{':'}
+
+	for _, option := range options {
+		option(ret)
+	}
+
+	return ret
+}
+
+/*
+	The buffers in which setQuery writes revised queries, reused across parses to spare allocations.
+*/
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+/*
+	Buffers grown past that size by a huge query are left to the garbage collector rather than pooled.
+*/
+const maxPooledBufferSize = 64 * 1024
+
+/*
+	setQuery parses out all named parameters, stores their locations, and
+	builds a "revised" query which uses positional parameters.
+	If the query is malformed, the first problem found is returned,
+	and the rest of the query is copied as it is.
+*/
+func (npq *NamedParameterQuery) setQuery(queryText string) error {
+
+	var revisedBuilder *bytes.Buffer
+	var character rune
+	var prefix rune
+	var braced bool
+	var quote rune
+	var next rune
+	var parameterName string
+	var width int
+	var tag string
+	var start int
+	var end int
+	var literalStart int
+	var runes runeCounter
+	var err error
+
+	npq.originalQuery = queryText
+	npq.namedParameters = nil
+	npq.placeholders = nil
+	npq.semicolons = nil
+	npq.positionalNames = nil
+	npq.literals = nil
+
+	// a static query is its own revised query, and needs neither a buffer nor a parse.
+	if npq.isPlainText(queryText) {
+
+		npq.revisedQuery = queryText
+		npq.parameters = []interface{}{}
+		npq.set = []bool{}
+		return nil
+	}
+
+	if !npq.namesOnly {
+		npq.parameters = make([]interface{}, 0, 8)
+		npq.set = make([]bool, 0, 8)
+	}
+
+	revisedBuilder = bufferPool.Get().(*bytes.Buffer)
+	revisedBuilder.Reset()
+
+	// only the built string is kept, the buffer itself goes back to the pool.
+	defer func() {
+		if revisedBuilder.Cap() <= maxPooledBufferSize {
+			bufferPool.Put(revisedBuilder)
+		}
+	}()
+
+	for i := 0; i < len(queryText); {
+
+		character, width = utf8.DecodeRuneInString(queryText[i:])
+		i += width
+
+		// if it's a dollar-quoted string, copy it verbatim up to the matching closing tag.
+		// This comes first, since "$" may also be a parameter prefix.
+		if character == '$' {
+
+			tag = dollarQuoteTag(queryText, i-width)
+
+			if len(tag) > 0 {
+
+				literalStart = revisedBuilder.Len()
+				end = strings.Index(queryText[i-width+len(tag):], tag)
+
+				if end < 0 {
+
+					if err == nil {
+						err = newQuoteError(queryText, i-width, "unterminated dollar-quoted string, expected a closing "+tag)
+					}
+					revisedBuilder.WriteString(queryText[i-width:])
+					npq.addLiteral(literalStart, revisedBuilder.Len())
+					break
+				}
+
+				end += i - width + 2*len(tag)
+				revisedBuilder.WriteString(queryText[i-width : end])
+				npq.addLiteral(literalStart, revisedBuilder.Len())
+				i = end
+				continue
+			}
+
+			// a hand-written "$1" would collide with our own numbered placeholders,
+			// and Postgres would then report a confusing type error rather than a placeholder one.
+			next, _ = utf8.DecodeRuneInString(queryText[i:])
+
+			if npq.dialect == DialectPostgres && next >= '0' && next <= '9' && !followsIdentifier(queryText, i-width) && err == nil {
+				err = newParseError(queryText, i-width, "positional placeholder found; mixing \"$N\" placeholders with named parameters is not supported")
+			}
+		}
+
+		// if it's a parameter prefix, do not write to builder, but grab name
+		if npq.isParameterPrefix(character) {
+
+			prefix = character
+			start = i - width
+
+			// "${name}" delimits the name explicitly.
+			braced = prefix == '$' && strings.HasPrefix(queryText[i:], "{")
+			if braced {
+				i++
+			}
+
+			// the character which ended the name is left for the main loop, as it may start a cast, a quote, etc.
+			end = npq.scanParameterName(queryText, i)
+			parameterName = queryText[i:end]
+			i = end
+
+			if braced {
+
+				if len(parameterName) <= 0 || !strings.HasPrefix(queryText[i:], "}") {
+
+					if err == nil {
+						err = newParseError(queryText, start, "malformed \"${\" parameter, expected a name and a closing }")
+					}
+					revisedBuilder.WriteString("${")
+					i = start + 2
+					continue
+				}
+				i++
+			}
+
+			if len(parameterName) <= 0 {
+
+				// a prefix which isn't followed by a name is plain text.
+				revisedBuilder.WriteString(string(prefix))
+				next, width = utf8.DecodeRuneInString(queryText[i:])
+
+				// a doubled prefix, such as the "::" cast, is legitimate; anything else is most likely a typo.
+				if next == prefix && width > 0 {
+
+					if !npq.doubledPrefixEscape {
+						revisedBuilder.WriteString(string(prefix))
+					}
+					i += width
+					continue
+				}
+
+				// ":=" is the MySQL assignment operator, and ":1" an array slice bound.
+				if (prefix == ':' && next == '=') || unicode.IsDigit(next) {
+
+					// unless it's a hand-written Oracle bind variable, whose number would collide with the generated ones.
+					if prefix == ':' && npq.dialect == DialectOracle && unicode.IsDigit(next) && err == nil {
+						err = newParseError(queryText, start, "positional placeholder found; mixing \":N\" placeholders with named parameters is not supported")
+					}
+					continue
+				}
+
+				if err == nil {
+					err = newParseError(queryText, start, fmt.Sprintf("parameter prefix '%c' not followed by a parameter name", prefix))
+				}
+				continue
+			}
+
+			npq.addParameter(revisedBuilder, parameterName, runes.offset(queryText, start))
+			continue
+		}
+
+		// if it's a "{name}" template parameter, grab the name between the braces.
+		if character == '{' && npq.braceParameters {
+
+			// "{{" and "}}" are literal braces.
+			if strings.HasPrefix(queryText[i:], "{") {
+				revisedBuilder.WriteString("{")
+				i++
+				continue
+			}
+
+			end = npq.scanParameterName(queryText, i)
+
+			if end > i && strings.HasPrefix(queryText[end:], "}") {
+				npq.addParameter(revisedBuilder, queryText[i:end], runes.offset(queryText, i-width))
+				i = end + 1
+				continue
+			}
+		}
+
+		if character == '}' && npq.braceParameters && strings.HasPrefix(queryText[i:], "}") {
+			revisedBuilder.WriteString("}")
+			i++
+			continue
+		}
+
+		// a "?" would be taken for one of our own positional placeholders by the driver,
+		// so operators such as the jsonb "?|" must be written "??|" instead.
+		if character == '?' && npq.usesQuestionMarks() {
+
+			if strings.HasPrefix(queryText[i:], "?") {
+				revisedBuilder.WriteString("?")
+				i++
+				continue
+			}
+
+			if err == nil {
+				err = newParseError(queryText, i-width, "positional placeholder found; mixing is not supported, write \"??\" for a literal \"?\"")
+			}
+		}
+
+		// if it's an escaped prefix, write the prefix alone, without starting a parameter.
+		if character == '\\' {
+
+			next, _ = utf8.DecodeRuneInString(queryText[i:])
+
+			if npq.isParameterPrefix(next) {
+				revisedBuilder.WriteString(string(next))
+				i += utf8.RuneLen(next)
+				continue
+			}
+		}
+
+		// if it's a comment, copy it verbatim up to its end.
+		if (character == '-' && strings.HasPrefix(queryText[i:], "-")) || (character == '#' && npq.hashComments) {
+
+			end = strings.IndexByte(queryText[i:], '\n')
+			if end < 0 {
+				end = len(queryText) - i
+			} else if npq.collapseWhitespace {
+				end++
+			}
+
+			literalStart = revisedBuilder.Len()
+			revisedBuilder.WriteString(queryText[i-width : i+end])
+			npq.addLiteral(literalStart, revisedBuilder.Len())
+			i += end
+			continue
+		}
+
+		if character == '/' && strings.HasPrefix(queryText[i:], "*") {
+
+			literalStart = revisedBuilder.Len()
+			end = strings.Index(queryText[i+1:], "*/")
+
+			if end < 0 {
+
+				if err == nil {
+					err = newParseError(queryText, i-width, "unterminated comment, expected a closing */")
+				}
+				revisedBuilder.WriteString(queryText[i-width:])
+				npq.addLiteral(literalStart, revisedBuilder.Len())
+				break
+			}
+
+			end += i + 3
+			revisedBuilder.WriteString(queryText[i-width : end])
+			npq.addLiteral(literalStart, revisedBuilder.Len())
+			i = end
+			continue
+		}
+
+		// if it's a PostgreSQL E'...' escape string, copy it verbatim, honoring backslash escapes such as "\'".
+		if (character == 'E' || character == 'e') && strings.HasPrefix(queryText[i:], "'") && !followsIdentifier(queryText, i-width) {
+
+			start = i - width
+			literalStart = revisedBuilder.Len()
+			revisedBuilder.WriteString(string(character) + "'")
+			i++
+
+			for ; ; {
+
+				if i >= len(queryText) {
+
+					if err == nil {
+						err = newQuoteError(queryText, start, "unterminated escape string literal, expected a closing '")
+					}
+					break
+				}
+
+				character, width = utf8.DecodeRuneInString(queryText[i:])
+				i += width
+				revisedBuilder.WriteString(queryText[i-width : i])
+
+				// the escaped character is copied whatever it is.
+				if character == '\\' && i < len(queryText) {
+
+					character, width = utf8.DecodeRuneInString(queryText[i:])
+					i += width
+					revisedBuilder.WriteString(queryText[i-width : i])
+					continue
+				}
+
+				if character == '\'' {
+
+					// a doubled quote is a literal quote here too.
+					if strings.HasPrefix(queryText[i:], "'") {
+						revisedBuilder.WriteByte('\'')
+						i++
+						continue
+					}
+					break
+				}
+			}
+			npq.addLiteral(literalStart, revisedBuilder.Len())
+			continue
+		}
+
+		// a run of whitespace is a single space, except at the start and after the newline ending a line comment.
+		if npq.collapseWhitespace && unicode.IsSpace(character) {
+
+			for i < len(queryText) {
+
+				character, width = utf8.DecodeRuneInString(queryText[i:])
+				if !unicode.IsSpace(character) {
+					break
+				}
+				i += width
+			}
+
+			if revisedBuilder.Len() > 0 && !bytes.HasSuffix(revisedBuilder.Bytes(), []byte("\n")) {
+				revisedBuilder.WriteByte(' ')
+			}
+			continue
+		}
+
+		// otherwise write. The original bytes are copied rather than the decoded rune,
+		// so that invalid UTF-8 is kept as it is instead of becoming U+FFFD.
+		revisedBuilder.WriteString(queryText[i-width : i])
+
+		if character == ';' {
+			npq.semicolons = append(npq.semicolons, i-width)
+		}
+
+		// if it's a quote, a double-quoted identifier or a MySQL backtick, continue writing to builder,
+		// but do not search for parameters.
+		if character == '\'' || character == '"' || character == '`' {
+
+			quote = character
+			start = i - width
+			literalStart = revisedBuilder.Len() - width
+
+			// a SQL Server N'...' national string literal starts at its prefix.
+			if quote == '\'' && start > 0 && (queryText[start-1] == 'N' || queryText[start-1] == 'n') && !followsIdentifier(queryText, start-1) {
+				start--
+			}
+
+			for ; ; {
+
+				if i >= len(queryText) {
+
+					if err == nil && quote != '\'' {
+						err = newQuoteError(queryText, start, fmt.Sprintf("unterminated quoted identifier, expected a closing %c", quote))
+					} else if err == nil {
+						err = newQuoteError(queryText, start, "unterminated string literal, expected a closing '")
+					}
+					break
+				}
+
+				character, width = utf8.DecodeRuneInString(queryText[i:])
+				i += width
+				revisedBuilder.WriteString(queryText[i-width : i])
+
+				if character == quote {
+
+					// a doubled quote is a literal quote, which doesn't end the literal.
+					if strings.HasPrefix(queryText[i:], string(quote)) {
+						revisedBuilder.WriteRune(quote)
+						i++
+						continue
+					}
+					break
+				}
+			}
+			npq.addLiteral(literalStart, revisedBuilder.Len())
+		}
+
+		// if it's a bracket-quoted identifier, copy it verbatim up to the closing bracket.
+		if character == '[' && npq.bracketIdentifiers {
+
+			start = i - width
+			literalStart = revisedBuilder.Len() - width
+
+			for ; ; {
+
+				if i >= len(queryText) {
+
+					if err == nil {
+						err = newQuoteError(queryText, start, "unterminated bracketed identifier, expected a closing ]")
+					}
+					break
+				}
+
+				character, width = utf8.DecodeRuneInString(queryText[i:])
+				i += width
+				revisedBuilder.WriteString(queryText[i-width : i])
+
+				if character == ']' {
+
+					// "]]" escapes a literal bracket inside the identifier.
+					if strings.HasPrefix(queryText[i:], "]") {
+						revisedBuilder.WriteByte(']')
+						i++
+						continue
+					}
+					break
+				}
+			}
+			npq.addLiteral(literalStart, revisedBuilder.Len())
+		}
+	}
+
+	if npq.namesOnly {
+		return err
+	}
+
+	npq.revisedQuery = revisedBuilder.String()
+
+	if npq.collapseWhitespace {
+		npq.revisedQuery = strings.TrimRight(npq.revisedQuery, " ")
+	}
+	return err
+}
+
+/*
+	addLiteral records that a literal, a quoted identifier or a comment was written from [start] to [end] in the revised query,
+	if npq query records them.
+*/
+func (npq *NamedParameterQuery) addLiteral(start int, end int) {
+
+	if npq.recordLiterals {
+		npq.literals = append(npq.literals, textSpan{start: start, end: end})
+	}
+}
+
+/*
+	The characters which setQuery may handle otherwise than by copying them, besides the parameter prefixes:
+	quotes, comments, placeholders, braces, brackets, escapes and statement ends.
+*/
+const specialCharacters = "'\"`-/#?$@{}[\\;"
+
+/*
+	isPlainText returns true if [queryText] has none of the characters setQuery handles specially,
+	so that it would be copied as it is into the revised query, without parameters.
+*/
+func (npq *NamedParameterQuery) isPlainText(queryText string) bool {
+
+	if npq.collapseWhitespace || strings.ContainsAny(queryText, specialCharacters) {
+		return false
+	}
+
+	for _, prefix := range npq.prefixes {
+		if strings.ContainsRune(queryText, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+	scanParameterName returns the offset at which the parameter name starting at [offset] in [queryText] ends.
+	The name ends on the first character which can't be part of it, or at the end of the query;
+	it is empty if the returned offset is [offset] itself.
+*/
+func (npq *NamedParameterQuery) scanParameterName(queryText string, offset int) int {
+
+	var character rune
+	var next rune
+	var width int
+	var i int
+
+	for i = offset; i < len(queryText); {
+
+		character, width = utf8.DecodeRuneInString(queryText[i:])
+
+		if npq.isParameterRune(character, i == offset) {
+			i += width
+			continue
+		}
+
+		// a dot joins the parts of a dotted name such as "user.id", but cannot start or end it.
+		if character == '.' && i > offset {
+
+			next, _ = utf8.DecodeRuneInString(queryText[i+width:])
+
+			if npq.isParameterRune(next, false) {
+				i += width
+				continue
+			}
+		}
+		break
+	}
+	return i
+}
+
+/*
+	runeCounter turns increasing byte offsets in a text into rune offsets, counting each rune once
+	however many offsets are turned.
+*/
+type runeCounter struct {
+	byteOffset int
+	runeOffset int
+}
+
+/*
+	offset returns the rune offset in [text] of the given [byteOffset], which must not be lower than the last one given.
+*/
+func (counter *runeCounter) offset(text string, byteOffset int) int {
+
+	counter.runeOffset += utf8.RuneCountInString(text[counter.byteOffset:byteOffset])
+	counter.byteOffset = byteOffset
+	return counter.runeOffset
+}
+
+/*
+	addParameter registers an occurrence of the parameter [parameterName], found at the rune [offset] in the original query,
+	and writes its positional placeholder to [revisedBuilder], unless only names are recorded.
+*/
+func (npq *NamedParameterQuery) addParameter(revisedBuilder *bytes.Buffer, parameterName string, offset int) {
+
+	var parameter *namedParameter
+	var spelling string
+	var placeholder string
+	var ordinal int
+
+	spelling = parameterName
+	parameterName = npq.normalizeName(parameterName)
+	parameter = npq.findParameter(parameterName)
+
+	if parameter == nil {
+		npq.namedParameters = append(npq.namedParameters, namedParameter{name: parameterName})
+		parameter = &npq.namedParameters[len(npq.namedParameters)-1]
+	}
+
+	if npq.namesOnly {
+		return
+	}
+
+	if npq.caseInsensitive {
+		parameter.addSpelling(spelling)
+	}
+
+	parameter.offsets = append(parameter.offsets, offset)
+
+	// add to positions, unless the placeholder of the previous occurrence is written again.
+	if npq.reusesOrdinals() && len(parameter.positions) > 0 {
+		ordinal = parameter.positions[0] + 1
+	} else {
+		parameter.positions = append(parameter.positions, len(npq.parameters))
+		npq.parameters = append(npq.parameters, npq.defaultValue)
+		npq.set = append(npq.set, false)
+		npq.positionalNames = append(npq.positionalNames, parameter.name)
+		ordinal = len(npq.parameters)
+	}
+
+	placeholder = npq.formatPlaceholder(parameterName, len(parameter.offsets)-1, ordinal)
+
+	npq.placeholders = append(npq.placeholders, placeholderSpan{
+		start: revisedBuilder.Len(),
+		end: revisedBuilder.Len() + len(placeholder),
+		position: ordinal - 1,
+		name: parameterName,
+		occurrence: len(parameter.offsets) - 1,
+	})
+	revisedBuilder.WriteString(placeholder)
+}
+
+/*
+	formatPlaceholder returns the placeholder of the [occurrence]th occurrence of the parameter [parameterName],
+	counting from 0, whose value is the [ordinal]th one, counting from 1, before the placeholder offset is added.
+*/
+func (npq *NamedParameterQuery) formatPlaceholder(parameterName string, occurrence int, ordinal int) string {
+
+	ordinal += npq.placeholderOffset
+
+	if npq.placeholder != nil {
+		return npq.placeholder(parameterName, occurrence, ordinal)
+	}
+	return npq.sqlDialect.Placeholder(parameterName, ordinal)
+}
+
+/*
+	reusesOrdinals returns true if every occurrence of a parameter is written with the same numbered placeholder,
+	so that its value is bound only once.
+*/
+func (npq *NamedParameterQuery) reusesOrdinals() bool {
+
+	if npq.placeholder != nil {
+		return npq.reusePlaceholders
+	}
+
+	if npq.sqlDialect.ReusesOrdinals() {
+		return true
+	}
+
+	// "?" and named placeholders have no number to reuse.
+	return npq.reusePlaceholders && npq.dialect != DialectMySQL && !npq.sqlDialect.PreservesNames()
+}
+
+/*
+	usesQuestionMarks returns true if the placeholders written start with "?",
+	so that a "?" already in the query would be taken for one of them.
+*/
+func (npq *NamedParameterQuery) usesQuestionMarks() bool {
+	return npq.dialect == DialectMySQL || npq.dialect == DialectSQLite
+}
+
+/*
+	normalizeName returns the name under which the parameter [name] is stored in namedParameters.
+*/
+func (npq *NamedParameterQuery) normalizeName(name string) string {
+
+	if npq.caseInsensitive {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+/*
+	findParameter returns the named parameter of the query whose name is the already normalized [parameterName],
+	or nil if the query does not use it.
+	Queries rarely have more than a handful of parameters, for which a linear search beats a map.
+*/
+func (npq *NamedParameterQuery) findParameter(parameterName string) *namedParameter {
+
+	for i := range npq.namedParameters {
+		if npq.namedParameters[i].name == parameterName {
+			return &npq.namedParameters[i]
+		}
+	}
+	return nil
+}
+
+/*
+	positionsOf returns the positional indices which match the parameter [parameterName],
+	or nil if the query does not use it.
+*/
+func (npq *NamedParameterQuery) positionsOf(parameterName string) []int {
+
+	var parameter *namedParameter
+
+	parameter = npq.findParameter(npq.normalizeName(parameterName))
+	if parameter == nil {
+		return nil
+	}
+	return parameter.positions
+}
+
+/*
+	addSpelling records the original [spelling] of a case-insensitive parameter name, once.
+*/
+func (parameter *namedParameter) addSpelling(spelling string) {
+
+	for _, known := range parameter.spellings {
+		if known == spelling {
+			return
+		}
+	}
+	parameter.spellings = append(parameter.spellings, spelling)
+}
+
+/*
+	isParameterPrefix returns true if the given [character] starts a named parameter.
+*/
+func (npq *NamedParameterQuery) isParameterPrefix(character rune) bool {
+
+	for _, prefix := range npq.prefixes {
+		if character == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+	isParameterRune returns true if the given [character] may be part of a parameter name.
+	[first] tells whether it would be the first character of the name,
+	which cannot be a digit unless WithNumericParameterNames was given.
+	Combining marks may follow the first character, so that a decomposed "naïve" is a single name.
+*/
+func (npq *NamedParameterQuery) isParameterRune(character rune, first bool) bool {
+
+	if unicode.IsDigit(character) && first && !npq.numericNames {
+		return false
+	}
+
+	if npq.nameRune != nil {
+		return npq.nameRune(character)
+	}
+	return unicode.IsLetter(character) || unicode.IsDigit(character) || character == '_' || (!first && unicode.IsMark(character))
+}
+
+/*
+	followsIdentifier returns true if the character before [offset] in [queryText] may be part of an identifier,
+	i.e. [offset] is in the middle of a word.
+*/
+func followsIdentifier(queryText string, offset int) bool {
+
+	var character rune
+
+	if offset <= 0 {
+		return false
+	}
+
+	character, _ = utf8.DecodeLastRuneInString(queryText[:offset])
+	return unicode.IsLetter(character) || unicode.IsDigit(character) || character == '_'
+}
+
+/*
+	dollarQuoteTag returns the PostgreSQL dollar-quote opening tag ("$$" or "$tag$") found at [offset] in [queryText],
+	or an empty string if there is none there.
+*/
+func dollarQuoteTag(queryText string, offset int) string {
+
+	var character rune
+	var width int
+
+	// a dollar inside an identifier (e.g. "col$1") never opens a quote.
+	if followsIdentifier(queryText, offset) {
+		return ""
+	}
+
+	for i := offset + 1; i < len(queryText); {
+
+		character, width = utf8.DecodeRuneInString(queryText[i:])
+
+		if character == '$' {
+			return queryText[offset : i+1]
+		}
+
+		// tags follow identifier rules; a leading digit means a positional parameter such as "$1".
+		if !unicode.IsLetter(character) && character != '_' && (i == offset+1 || !unicode.IsDigit(character)) {
+			return ""
+		}
+
+		i += width
+	}
+	return ""
+}
+
+/*
+	GetParsedQuery returns a version of the original query text
+	whose named parameters have been replaced by positional parameters.
+	A parameter whose value is a slice (other than a []byte or a driver.Valuer) gets one placeholder per element,
+	e.g. "IN (:ids)" becomes "IN ($1, $2, $3)" for a 3 elements slice, and the placeholders which follow are renumbered;
+	so the parsed query must be got after values are set. See WithoutSliceExpansion.
+*/
+func (npq *NamedParameterQuery) GetParsedQuery() string {
+
+	var ret string
+
+	if !npq.expandsSlices() {
+		return npq.revisedQuery
+	}
+
+	ret, _ = npq.expandSlices()
+	return ret
+}
+
+/*
+	GetParsedParameters returns an array of parameter objects that match the positional parameter list
+	from GetParsedQuery
+*/
+func (npq *NamedParameterQuery) GetParsedParameters() []interface{} {
+
+	var ret []interface{}
+
+	if !npq.expandsSlices() {
+		return npq.parameters
+	}
+
+	_, ret = npq.expandSlices()
+	return ret
+}
+
+/*
+	GetParsedParametersChecked works like GetParsedParameters, but returns the error of Validate instead,
+	naming every parameter left unset, so that an incomplete binding never reaches the driver as NULLs.
+	The error reported by Err, if any, is returned first.
+*/
+func (npq *NamedParameterQuery) GetParsedParametersChecked() ([]interface{}, error) {
+
+	var err error
+
+	if npq.err != nil {
+		return nil, npq.err
+	}
+
+	err = npq.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return npq.GetParsedParameters(), nil
+}
+
+/*
+	GetParsedQueryAndParameters returns both GetParsedQuery and GetParsedParameters in one call,
+	ready to be given to e.g. "connection.QueryRow(query, parameters...)".
+*/
+func (npq *NamedParameterQuery) GetParsedQueryAndParameters() (string, []interface{}) {
+
+	if !npq.expandsSlices() {
+		return npq.revisedQuery, npq.parameters
+	}
+	return npq.expandSlices()
+}
+
+/*
+	String returns the query and parameters GetParsedQueryAndParameters gives the driver, for logging and debugging, e.g.
+		SELECT * FROM table WHERE col1 = $1 AND col2 = $2 [1: "foo", 2: <unset>]
+	Each parameter is labelled with the ordinal of its placeholder, SetPlaceholderOffset included,
+	and the elements of expanded slices are listed one by one.
+	Parameters which were never given a value are shown as <unset>, and string values are quoted.
+*/
+func (npq *NamedParameterQuery) String() string {
+
+	var ret bytes.Buffer
+	var query string
+	var parameters []interface{}
+	var unset []bool
+	var count int
+
+	query, parameters = npq.GetParsedQueryAndParameters()
+
+	// tell which of the parameters given to the driver were never set, an expanded slice counting once per element.
+	for i, value := range npq.parameters {
+
+		count = 1
+		if npq.set[i] && npq.canExpandSlices() && isExpandable(value) && reflect.ValueOf(value).Len() > 0 {
+			count = reflect.ValueOf(value).Len()
+		}
+
+		for ; count > 0; count-- {
+			unset = append(unset, !npq.set[i])
+		}
+	}
+
+	ret.WriteString(query)
+	ret.WriteString(" [")
+
+	for i, value := range parameters {
+
+		if i > 0 {
+			ret.WriteString(", ")
+		}
+
+		fmt.Fprintf(&ret, "%d: ", npq.placeholderOffset+i+1)
+
+		if unset[i] {
+			ret.WriteString("<unset>")
+		} else if text, isString := value.(string); isString {
+			fmt.Fprintf(&ret, "%q", text)
+		} else {
+			fmt.Fprintf(&ret, "%v", value)
+		}
+	}
+
+	ret.WriteString("]")
+	return ret.String()
+}
+
+/*
+	GetParsedArguments returns the arguments to give the driver along with GetParsedQuery:
+	GetNamedParameters if the dialect preserves names (see SQLDialect), and GetParsedParameters otherwise.
+*/
+func (npq *NamedParameterQuery) GetParsedArguments() []interface{} {
+
+	if npq.sqlDialect.PreservesNames() {
+		return npq.GetNamedParameters()
+	}
+	return npq.GetParsedParameters()
+}
+
+/*
+	GetPgxNamedArgs returns the value of every distinct parameter by name, for the pgx v5 driver,
+	whose pgx.NamedArgs binds the "@name" placeholders written by DialectPgx:
+		query, _ := NewNamedParameterQueryForDialect("SELECT * FROM table WHERE col1 = :foo", DialectPgx)
+		conn.Query(ctx, query.GetParsedQuery(), pgx.NamedArgs(query.GetPgxNamedArgs()))
+	Values are the same as those of GetNamedParameters: a parameter used several times takes the value
+	of its first occurrence, and unset parameters are nil unless WithUnsetParametersOmitted was given.
+*/
+func (npq *NamedParameterQuery) GetPgxNamedArgs() map[string]interface{} {
+
+	var ret map[string]interface{}
+	var namedArg sql.NamedArg
+
+	ret = make(map[string]interface{}, len(npq.namedParameters))
+
+	for _, parameter := range npq.GetNamedParameters() {
+
+		namedArg = parameter.(sql.NamedArg)
+		ret[namedArg.Name] = namedArg.Value
+	}
+	return ret
+}
+
+/*
+	GetParsedNamedArgs returns the same values as GetParsedParameters, each wrapped as a sql.NamedArg
+	named after its "@pN" placeholder, e.g. sql.Named("p1", value) for "@p1".
+	It is meant for DialectSQLServer, whose driver binds "@pN" placeholders by name:
+		db.QueryContext(ctx, query.GetParsedQuery(), query.GetParsedNamedArgs()...)
+*/
+func (npq *NamedParameterQuery) GetParsedNamedArgs() []interface{} {
+
+	var ret []interface{}
+	var parameters []interface{}
+
+	parameters = npq.GetParsedParameters()
+	ret = make([]interface{}, len(parameters))
+
+	for i, value := range parameters {
+		ret[i] = sql.Named(fmt.Sprintf("p%d", npq.placeholderOffset+i+1), value)
+	}
+	return ret
+}
+
+/*
+	GetNamedParameters returns one sql.NamedArg per distinct parameter, named after it, in order of first appearance.
+	It is meant for DialectNamed, whose revised query keeps the ":name" placeholders, with drivers which bind named arguments:
+		db.QueryContext(ctx, query.GetParsedQuery(), query.GetNamedParameters()...)
+	A parameter used several times takes the value of its first occurrence.
+	Parameters which were never given a value are included with a nil value, i.e. bound as NULL,
+	unless WithUnsetParametersOmitted was given, in which case they are left out.
+	A slice value is expanded as GetParsedQuery expands it, into one argument per element named after
+	its placeholder, e.g. "ids_0" and "ids_1" for ":ids", unless WithoutSliceExpansion was given.
+*/
+func (npq *NamedParameterQuery) GetNamedParameters() []interface{} {
+
+	var ret []interface{}
+	var reflected reflect.Value
+	var value interface{}
+	var position int
+
+	ret = make([]interface{}, 0, len(npq.namedParameters))
+
+	for _, parameter := range npq.namedParameters {
+
+		position = parameter.positions[0]
+		value = npq.parameters[position]
+
+		if npq.omitUnsetParameters && !npq.set[position] && !npq.hasDefault {
+			continue
+		}
+
+		if !npq.set[position] || !npq.canExpandSlices() || !isExpandable(value) {
+			ret = append(ret, sql.Named(parameter.name, value))
+			continue
+		}
+
+		reflected = reflect.ValueOf(value)
+
+		for i := 0; i < reflected.Len(); i++ {
+			ret = append(ret, sql.Named(elementName(parameter.name, i), normalizeValue(reflected.Index(i).Interface())))
+		}
+
+		// an empty slice is a single NULL, as in the parsed query.
+		if reflected.Len() == 0 {
+			ret = append(ret, sql.Named(elementName(parameter.name, 0), nil))
+		}
+	}
+	return ret
+}
+
+/*
+	SetPlaceholderOffset makes numbered placeholders start at [offset]+1, as WithPlaceholderOffset does,
+	and rewrites the parsed query accordingly. Values set so far are kept, and so are rows given to ExpandRows,
+	since the placeholders already written are renumbered rather than the query parsed again.
+*/
+func (npq *NamedParameterQuery) SetPlaceholderOffset(offset int) {
+
+	var query bytes.Buffer
+	var placeholders []placeholderSpan
+	var placeholder string
+	var last int
+
+	npq.placeholderOffset = offset
+
+	// the spans may be shared with clones, so they are copied rather than updated in place.
+	placeholders = make([]placeholderSpan, len(npq.placeholders))
+
+	for i, span := range npq.placeholders {
+
+		query.WriteString(npq.revisedQuery[last:span.start])
+		last = span.end
+
+		placeholder = npq.formatPlaceholder(span.name, span.occurrence, span.position+1)
+
+		span.start = query.Len()
+		span.end = query.Len() + len(placeholder)
+		placeholders[i] = span
+
+		query.WriteString(placeholder)
+	}
+
+	query.WriteString(npq.revisedQuery[last:])
+
+	npq.revisedQuery = query.String()
+	npq.placeholders = placeholders
+}
+
+/*
+	GetHighestOrdinal returns the ordinal of the last numbered placeholder of the parsed query,
+	i.e. the placeholder offset plus GetParameterCount, which is the offset to give to the next fragment
+	of a query built from several ones.
+*/
+func (npq *NamedParameterQuery) GetHighestOrdinal() int {
+	return npq.placeholderOffset + npq.GetParameterCount()
+}
+
+/*
+	GetParameterCount returns the number of positional parameters in the parsed query,
+	i.e. the length of GetParsedParameters. A parameter used several times is counted each time,
+	unless its placeholder is reused, as with DialectSQLServer, DialectSQLite or WithReusedPlaceholders.
+*/
+func (npq *NamedParameterQuery) GetParameterCount() int {
+
+	if !npq.expandsSlices() {
+		return len(npq.parameters)
+	}
+	return len(npq.GetParsedParameters())
+}
+
+/*
+	GetDistinctParameterCount returns the number of distinct parameter names used in the query.
+*/
+func (npq *NamedParameterQuery) GetDistinctParameterCount() int {
+	return len(npq.namedParameters)
+}
+
+/*
+	GetParameterNames returns the distinct names of the parameters used in the query,
+	in the order they first appear in it.
+*/
+func (npq *NamedParameterQuery) GetParameterNames() []string {
+
+	var ret []string
+
+	ret = make([]string, 0, len(npq.namedParameters))
 
-	// Replace arg
-	replaceArg string
+	for _, parameter := range npq.namedParameters {
+		ret = append(ret, parameter.name)
+	}
+	return ret
 }
 
 /*
-	NewNamedParameterQuery creates a new named parameter query using the given [queryText] as a SQL query which
-	contains named parameters. Named parameters are identified by starting with a ":"
-	e.g., ":name" refers to the parameter "name", and ":foo" refers to the parameter "foo".
-	Except for their names, named parameters follow all the same rules as positional parameters;
-	they cannot be inside quoted strings, and cannot inject statements into a query. They can only
-	be used to insert values.
+	GetParameterOffsets returns, for each parameter name, the rune offsets in the original query text
+	at which the parameter occurs, in order, i.e. their indices in []rune(queryText), so that they are character offsets
+	even when the query has multibyte characters. Each offset points at the parameter's prefix (e.g. the ":" of ":name").
+	The returned map is a copy, and may be freely modified.
 */
-func NewNamedParameterQuery(queryText string, argIndication string) *NamedParameterQuery {
+func (npq *NamedParameterQuery) GetParameterOffsets() map[string][]int {
 
-	var ret *NamedParameterQuery
+	var ret map[string][]int
 
-	// TODO: I don't like using a map for such a small amount of elements.
-	// If npq becomes a bottleneck for anyone, the first thing to do would
-	// be to make a slice and search routine for parameter positions.
-	ret = new(NamedParameterQuery)
-	ret.positions = make(map[string][]int, 8)
-	ret.replaceArg = argIndication
-	ret.setQuery(queryText)
+	ret = make(map[string][]int, len(npq.namedParameters))
 
+	for _, parameter := range npq.namedParameters {
+		ret[parameter.name] = append([]int(nil), parameter.offsets...)
+	}
 	return ret
 }
 
 /*
-	setQuery parses out all named parameters, stores their locations, and
-	builds a "revised" query which uses positional parameters.
+	GetPositionalNames returns the name of the parameter of each positional parameter, in the order of GetParsedParameters,
+	e.g. ["foo", "bar", "foo"] for ":foo, :bar, :foo", to attach type hints or log where each value comes from.
+	Like GetPositions, it describes the parsed query before slice values are expanded.
+	The returned slice is a copy, and may be freely modified.
 */
-func (npq *NamedParameterQuery) setQuery(queryText string) {
+func (npq *NamedParameterQuery) GetPositionalNames() []string {
+	return append([]string{}, npq.positionalNames...)
+}
 
-	var revisedBuilder bytes.Buffer
-	var parameterBuilder bytes.Buffer
-	var position []int
-	var character rune
-	var parameterName string
-	var width int
-	var positionIndex int
-	var nbParameter = 0
+/*
+	GetPositions returns, for each parameter name, the indices in GetParsedParameters of its values, in order,
+	counting from 0. A parameter whose occurrences reuse a single placeholder has a single position.
+	Positions are those of the parsed query before slice values are expanded.
+	The returned map is a copy, and may be freely modified.
+*/
+func (npq *NamedParameterQuery) GetPositions() map[string][]int {
 
-	npq.originalQuery = queryText
-	positionIndex = 0
+	var ret map[string][]int
 
-	for i := 0; i < len(queryText); {
+	ret = make(map[string][]int, len(npq.namedParameters))
 
-		character, width = utf8.DecodeRuneInString(queryText[i:])
-		i += width
+	for _, parameter := range npq.namedParameters {
+		ret[parameter.name] = append([]int(nil), parameter.positions...)
+	}
+	return ret
+}
+
+/*
+	GetParameterSpellings returns the distinct spellings of the parameter [parameterName] found in the query,
+	in order of appearance. This is mostly useful with WithCaseInsensitiveNames, where e.g. ":UserId" and ":userid"
+	are both spellings of "userid"; otherwise, it is the name itself if the query uses it.
+*/
+func (npq *NamedParameterQuery) GetParameterSpellings(parameterName string) []string {
 
-		// if it's a colon, do not write to builder, but grab name
-		if character == ':' {
+	var parameter *namedParameter
 
-			for ; ; {
+	parameter = npq.findParameter(npq.normalizeName(parameterName))
 
-				character, width = utf8.DecodeRuneInString(queryText[i:])
-				i += width
+	if parameter == nil {
+		return nil
+	}
 
-				if unicode.IsLetter(character) || unicode.IsDigit(character) {
-					parameterBuilder.WriteString(string(character))
-				} else {
-					break
-				}
-			}
+	if npq.caseInsensitive {
+		return append([]string(nil), parameter.spellings...)
+	}
+	return []string{parameter.name}
+}
 
-			// add to positions
-			parameterName = parameterBuilder.String()
-			nbParameter++
-			position = npq.positions[parameterName]
-			npq.positions[parameterName] = append(position, positionIndex)
-			positionIndex++
-
-			if npq.replaceArg == ":" {
-				revisedBuilder.WriteString(":" + parameterName)
-			} else if npq.replaceArg == "$" {
-				revisedBuilder.WriteString(fmt.Sprintf("%s%d", npq.replaceArg, nbParameter))
-			} else {
-				revisedBuilder.WriteString("?")
-			}
+/*
+	Reset clears every value bound so far, so that npq query can be bound again
+	without re-parsing the original query text. The parsed query and parameter positions are kept.
+	Reset is not safe for concurrent use; callers sharing a query across goroutines
+	must synchronize access themselves.
+*/
+func (npq *NamedParameterQuery) Reset() {
+	npq.parameters = npq.unsetValues(len(npq.parameters))
+	npq.set = make([]bool, len(npq.set))
+	npq.err = nil
+}
 
-			parameterBuilder.Reset()
+/*
+	ClearValues clears every value bound so far, as Reset does, but in place rather than by allocating new storage,
+	so it is as cheap as possible for a query reused by every request.
+	Unlike after Reset, the slice last returned by GetParsedParameters is cleared too, since it is the same storage:
+	ClearValues must not be called while a caller still uses it, e.g. while the query runs in another goroutine.
+*/
+func (npq *NamedParameterQuery) ClearValues() {
 
-			if width <= 0 {
-				break
-			}
+	for i := range npq.parameters {
+		npq.parameters[i] = npq.defaultValue
+		npq.set[i] = false
+	}
+	npq.err = nil
+}
+
+/*
+	SetDefault makes [defaultValue] the value of every positional parameter which isn't given one,
+	instead of nil, e.g. to bind a parameter left out of a map as some sentinel.
+	Parameters keep the default until they are set, and get it back when npq query is Reset.
+	Since unset parameters are then intentional, MissingParameters no longer reports them
+	and GetNamedParameters no longer omits them, though IsValueSet is still false for them.
+*/
+func (npq *NamedParameterQuery) SetDefault(defaultValue interface{}) {
+
+	npq.defaultValue = normalizeValue(defaultValue)
+	npq.hasDefault = true
+
+	for i := range npq.parameters {
+		if !npq.set[i] {
+			npq.parameters[i] = npq.defaultValue
 		}
+	}
+}
 
-		// otherwise write.
-		revisedBuilder.WriteString(string(character))
+/*
+	unsetValues returns [count] positional parameters which were never given a value.
+*/
+func (npq *NamedParameterQuery) unsetValues(count int) []interface{} {
 
-		// if it's a quote, continue writing to builder, but do not search for parameters.
-		if character == '\'' {
+	var ret []interface{}
 
-			for ; ; {
+	ret = make([]interface{}, count)
 
-				character, width = utf8.DecodeRuneInString(queryText[i:])
-				i += width
-				revisedBuilder.WriteString(string(character))
+	if npq.defaultValue != nil {
+		for i := range ret {
+			ret[i] = npq.defaultValue
+		}
+	}
+	return ret
+}
 
-				if character == '\'' {
-					break
-				}
-			}
+/*
+	Clone returns a copy of npq query which can be bound independently: setting values on either one
+	never affects the other. The copy has the same parsed query, parameters and SetDefault value, but no values set,
+	so a query can be parsed once and cloned for every use, e.g. by each goroutine.
+	The parsed pieces, which are never modified once parsed, are shared rather than copied,
+	so cloning only allocates the values of the copy, whatever the length of the query.
+*/
+func (npq *NamedParameterQuery) Clone() *NamedParameterQuery {
+
+	var ret *NamedParameterQuery
+
+	ret = new(NamedParameterQuery)
+	*ret = *npq
+
+	ret.parameters = npq.unsetValues(len(npq.parameters))
+	ret.set = make([]bool, len(npq.set))
+	ret.err = nil
+	return ret
+}
+
+/*
+	IsValueSet returns true if a value was given to every occurrence of the parameter [parameterName]
+	since npq query was parsed or last Reset. A nil value counts as set.
+	It returns false if the query does not use [parameterName].
+*/
+func (npq *NamedParameterQuery) IsValueSet(parameterName string) bool {
+
+	var positions []int
+
+	positions = npq.positionsOf(parameterName)
+
+	for _, position := range positions {
+		if !npq.set[position] {
+			return false
 		}
 	}
+	return len(positions) > 0
+}
 
-	npq.revisedQuery = revisedBuilder.String()
-	npq.parameters = make([]interface{}, positionIndex)
+/*
+	HasParameter returns true if the query uses the parameter [parameterName],
+	matched regardless of case with WithCaseInsensitiveNames.
+*/
+func (npq *NamedParameterQuery) HasParameter(parameterName string) bool {
+	return npq.findParameter(npq.normalizeName(parameterName)) != nil
 }
 
 /*
-	GetParsedQuery returns a version of the original query text
-	whose named parameters have been replaced by positional parameters.
+	GetParameterOccurrences returns the number of positional parameters the parameter [parameterName] fills,
+	i.e. the number of times it is used in the query, or 1 if its occurrences reuse a single placeholder.
+	It returns 0 if the query does not use [parameterName].
 */
-func (npq *NamedParameterQuery) GetParsedQuery() string {
-	return npq.revisedQuery
+func (npq *NamedParameterQuery) GetParameterOccurrences(parameterName string) int {
+	return len(npq.positionsOf(parameterName))
 }
 
 /*
-	GetParsedParameters returns an array of parameter objects that match the positional parameter list
-	from GetParsedQuery
+	MissingParameters returns the names of the parameters which have at least one occurrence
+	never given a value since npq query was parsed or last Reset, in the order they first appear in the query.
+	A nil value counts as set, since it is a legitimate NULL. It returns nil if every parameter is set,
+	or once SetDefault was called.
 */
-func (npq *NamedParameterQuery) GetParsedParameters() []interface{} {
-	return npq.parameters
+func (npq *NamedParameterQuery) MissingParameters() []string {
+
+	var ret []string
+
+	if npq.hasDefault {
+		return nil
+	}
+
+	for _, parameter := range npq.namedParameters {
+
+		for _, position := range parameter.positions {
+
+			if !npq.set[position] {
+				ret = append(ret, parameter.name)
+				break
+			}
+		}
+	}
+	return ret
+}
+
+/*
+	Validate returns an error naming every parameter listed by MissingParameters,
+	or nil if every parameter of the query was given a value.
+*/
+func (npq *NamedParameterQuery) Validate() error {
+
+	var missing []string
+
+	missing = npq.MissingParameters()
+
+	if len(missing) > 0 {
+		return fmt.Errorf("unable to use query: %w for parameters '%s'", ErrMissingValue, strings.Join(missing, "', '"))
+	}
+	return nil
+}
+
+/*
+	Bind builds a fresh positional parameter list from the given [values], matching GetParsedQuery.
+	Unlike SetValuesFromMap, npq query is never modified, so a single parsed query may be
+	bound concurrently by several goroutines, as long as none of them calls a Set method on it.
+	Values are looked up the same way SetValuesFromMap does.
+	If a parameter of the query has no entry in [values], an error is returned.
+	Entries of [values] that aren't part of the query are ignored.
+	Slice values are bound as they are, since the query itself can't be expanded for them.
+*/
+func (npq *NamedParameterQuery) Bind(values map[string]interface{}) ([]interface{}, error) {
+
+	var ret []interface{}
+	var value interface{}
+	var present bool
+
+	ret = make([]interface{}, len(npq.parameters))
+
+	for _, parameter := range npq.namedParameters {
+
+		value, present = lookupValue(values, parameter.name, npq.caseInsensitive)
+		if !present {
+			return nil, fmt.Errorf("unable to bind query values: %w for parameter '%s'", ErrMissingValue, parameter.name)
+		}
+
+		for _, position := range parameter.positions {
+			ret[position] = normalizeValue(value)
+		}
+	}
+	return ret, nil
 }
 
 /*
 	SetValue sets the value of the given [parameterName] to the given [parameterValue].
 	If the parsed query does not have a placeholder for the given [parameterName],
 	npq method does nothing.
+	A nil [parameterValue] is a SQL NULL, and so is a typed nil pointer such as (*int)(nil),
+	which is stored as a plain nil the way database/sql would convert it; unless its type implements
+	driver.Valuer with a pointer receiver, which database/sql calls even for a nil pointer.
 */
 func (npq *NamedParameterQuery) SetValue(parameterName string, parameterValue interface{}) {
 
-	for _, position := range npq.positions[parameterName] {
-		npq.parameters[position] = parameterValue
+	for _, position := range npq.positionsOf(parameterName) {
+		npq.setPosition(position, parameterValue)
+	}
+}
+
+/*
+	SetValueStrict works like SetValue, but first checks that database/sql can convert [parameterValue]
+	to a driver.Value, using driver.DefaultParameterConverter: nil, numbers, booleans, strings, []byte, time.Time,
+	pointers to those and driver.Valuer implementations are accepted, while maps, channels, funcs and other structs
+	are rejected with an error naming the parameter and the Go type, and the value is not set.
+	Slices which are expanded into one placeholder per element are checked element by element.
+*/
+func (npq *NamedParameterQuery) SetValueStrict(parameterName string, parameterValue interface{}) error {
+
+	var err error
+
+	err = npq.checkValue(parameterValue)
+	if err != nil {
+		return fmt.Errorf("unable to set query value: parameter '%s' has unsupported type %T: %v", parameterName, parameterValue, err)
+	}
+
+	npq.SetValue(parameterName, parameterValue)
+	return nil
+}
+
+/*
+	checkValue returns an error if [value], or each of its elements if it is a slice to expand,
+	can't be converted to a driver.Value by database/sql.
+*/
+func (npq *NamedParameterQuery) checkValue(value interface{}) error {
+
+	var reflected reflect.Value
+	var err error
+
+	if !npq.canExpandSlices() || !isExpandable(value) {
+		_, err = driver.DefaultParameterConverter.ConvertValue(value)
+		return err
+	}
+
+	reflected = reflect.ValueOf(value)
+
+	for i := 0; i < reflected.Len(); i++ {
+
+		_, err = driver.DefaultParameterConverter.ConvertValue(reflected.Index(i).Interface())
+		if err != nil {
+			return fmt.Errorf("element %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+/*
+	setPosition sets the positional parameter at [position] to [parameterValue], and records it as set.
+*/
+func (npq *NamedParameterQuery) setPosition(position int, parameterValue interface{}) {
+	npq.parameters[position] = normalizeValue(parameterValue)
+	npq.set[position] = true
+}
+
+/*
+	normalizeValue turns typed nil pointers into a plain nil, so that drivers see a SQL NULL.
+	Nil pointers whose type has a pointer receiver Value method are kept, since database/sql calls that method,
+	which may handle a nil receiver, as it only skips Value methods with a value receiver.
+*/
+func normalizeValue(value interface{}) interface{} {
+
+	var reflected reflect.Value
+
+	reflected = reflect.ValueOf(value)
+
+	if reflected.Kind() == reflect.Ptr && reflected.IsNil() && !hasPointerValuer(reflected.Type()) {
+		return nil
+	}
+	return value
+}
+
+/*
+	SetValueAt sets the value of a single occurrence of the given [parameterName]: the [occurrence]th one,
+	counting from 0 in the order they appear in the query. Other occurrences keep their values.
+	e.g. with "BETWEEN :range AND :range", SetValueAt("range", 1, 10) sets the upper bound only.
+	If the query does not use [parameterName] that many times, an error is returned.
+	So it is when occurrences share a reused placeholder (see WithReusedPlaceholders), since they can't have different values.
+*/
+func (npq *NamedParameterQuery) SetValueAt(parameterName string, occurrence int, parameterValue interface{}) error {
+
+	var parameter *namedParameter
+	var positions []int
+	var occurrences int
+
+	parameter = npq.findParameter(npq.normalizeName(parameterName))
+	if parameter == nil {
+		return fmt.Errorf("unable to set query value: %w '%s'", ErrUnknownParameter, parameterName)
+	}
+
+	positions = parameter.positions
+	occurrences = len(parameter.offsets)
+
+	if occurrence < 0 || occurrence >= occurrences {
+		return fmt.Errorf("unable to set query value: parameter '%s' has no occurrence %d, it is used %d times", parameterName, occurrence, occurrences)
+	}
+
+	if len(positions) < occurrences {
+		return fmt.Errorf("unable to set query value: the %d occurrences of parameter '%s' share a single placeholder", occurrences, parameterName)
+	}
+
+	npq.setPosition(positions[occurrence], parameterValue)
+	return nil
+}
+
+/*
+	WithValue works like SetValue, but returns npq query so that calls can be chained:
+		query.WithValue("foo", 1).WithValue("bar", 2)
+	Like every chained setter, it does nothing once a chained setter failed; see Err.
+*/
+func (npq *NamedParameterQuery) WithValue(parameterName string, parameterValue interface{}) *NamedParameterQuery {
+
+	if npq.err == nil {
+		npq.SetValue(parameterName, parameterValue)
+	}
+	return npq
+}
+
+/*
+	WithValuesFromMap works like SetValuesFromMap, but returns npq query so that calls can be chained.
+*/
+func (npq *NamedParameterQuery) WithValuesFromMap(parameters map[string]interface{}) *NamedParameterQuery {
+
+	if npq.err == nil {
+		npq.SetValuesFromMap(parameters)
+	}
+	return npq
+}
+
+/*
+	WithValueStrict works like SetValueStrict, but returns npq query so that calls can be chained,
+	and keeps its error, if any, for Err.
+*/
+func (npq *NamedParameterQuery) WithValueStrict(parameterName string, parameterValue interface{}) *NamedParameterQuery {
+
+	if npq.err == nil {
+		npq.err = npq.SetValueStrict(parameterName, parameterValue)
+	}
+	return npq
+}
+
+/*
+	WithValues works like SetValues, but returns npq query so that calls can be chained,
+	and keeps its error, if any, for Err.
+*/
+func (npq *NamedParameterQuery) WithValues(pairs ...interface{}) *NamedParameterQuery {
+
+	if npq.err == nil {
+		npq.err = npq.SetValues(pairs...)
+	}
+	return npq
+}
+
+/*
+	WithValuesFromStruct works like SetValuesFromStruct, but returns npq query so that calls can be chained,
+	and keeps its error, if any, for Err.
+*/
+func (npq *NamedParameterQuery) WithValuesFromStruct(parameters interface{}) *NamedParameterQuery {
+
+	if npq.err == nil {
+		npq.err = npq.SetValuesFromStruct(parameters)
+	}
+	return npq
+}
+
+/*
+	WithValuesFromJSON works like SetValuesFromJSON, but returns npq query so that calls can be chained,
+	and keeps its error, if any, for Err.
+*/
+func (npq *NamedParameterQuery) WithValuesFromJSON(data []byte) *NamedParameterQuery {
+
+	if npq.err == nil {
+		npq.err = npq.SetValuesFromJSON(data)
+	}
+	return npq
+}
+
+/*
+	WithValuesFromURLValues works like SetValuesFromURLValues, but returns npq query so that calls can be chained,
+	and keeps its error, if any, for Err.
+*/
+func (npq *NamedParameterQuery) WithValuesFromURLValues(values url.Values) *NamedParameterQuery {
+
+	if npq.err == nil {
+		npq.err = npq.SetValuesFromURLValues(values)
+	}
+	return npq
+}
+
+/*
+	Err returns the first error returned to a chained setter, such as WithValuesFromStruct, since npq query
+	was parsed or last Reset, or nil. As with a bufio.Writer, once a chained setter failed,
+	those which follow do nothing, so that the error is checked once, at the end of the chain:
+		err := query.WithValuesFromStruct(user).WithValueStrict("since", since).Err()
+	Errors returned by the Set methods themselves are not kept.
+*/
+func (npq *NamedParameterQuery) Err() error {
+	return npq.err
+}
+
+/*
+	SetValues sets parameter values from alternating name/value [pairs], e.g. SetValues("foo", 1, "bar", 2).
+	npq is equivalent to calling SetValue for every pair.
+	If the number of arguments is odd, or a name isn't a string, an error is returned and no value is set.
+*/
+func (npq *NamedParameterQuery) SetValues(pairs ...interface{}) error {
+
+	var name string
+	var isString bool
+
+	if len(pairs)%2 != 0 {
+		return errors.New("unable to set query values: odd number of name/value arguments")
 	}
+
+	for i := 0; i < len(pairs); i += 2 {
+
+		_, isString = pairs[i].(string)
+		if !isString {
+			return fmt.Errorf("unable to set query values: parameter name at argument %d is a %T, not a string", i, pairs[i])
+		}
+	}
+
+	for i := 0; i < len(pairs); i += 2 {
+
+		name = pairs[i].(string)
+		npq.SetValue(name, pairs[i+1])
+	}
+	return nil
 }
 
 /*
@@ -222,62 +2121,77 @@ func (npq *NamedParameterQuery) SetValue(parameterName string, parameterValue in
 	in the given [parameters] map.
 	If there are any keys/values present in the map that aren't part of the query,
 	they are ignored.
+	Dotted parameter names such as ":user.id" are also looked up in nested maps,
+	so that {"user": {"id": 7}} fills ":user.id".
 */
 func (npq *NamedParameterQuery) SetValuesFromMap(parameters map[string]interface{}) {
 
-	for name, value := range parameters {
-		npq.SetValue(name, value)
+	var value interface{}
+	var present bool
+
+	for _, parameter := range npq.namedParameters {
+
+		value, present = lookupValue(parameters, parameter.name, npq.caseInsensitive)
+		if present {
+			for _, position := range parameter.positions {
+				npq.setPosition(position, value)
+			}
+		}
 	}
 }
 
 /*
-	SetValuesFromStruct uses reflection to find every public field of the given struct [parameters]
-	and set their key/value as named parameters in npq query.
-	If the given [parameters] is not a struct, npq will return an error.
-	If you do not wish for a field in the struct to be added by its literal name,
-	The struct may optionally specify the sqlParameterName as a tag on the field.
-	e.g., a struct field may say something like:
-		type Test struct {
-			Foo string `sqlParameterName:"foobar"`
-		}
+	lookupValue finds the value of the parameter [name] in the given [values].
+	A dotted name such as "user.id" which isn't itself a key of [values]
+	is resolved through nested maps, e.g. {"user": {"id": 7}}.
+	If [foldCase] is true, keys are matched regardless of their case.
 */
-func (npq *NamedParameterQuery) SetValuesFromStruct(parameters interface{}) error {
-
-	var fieldValues reflect.Value
-	var fieldValue reflect.Value
-	var parameterType reflect.Type
-	var parameterField reflect.StructField
-	var queryTag string
-	var visibilityCharacter rune
+func lookupValue(values map[string]interface{}, name string, foldCase bool) (interface{}, bool) {
 
-	fieldValues = reflect.ValueOf(parameters)
+	var value interface{}
+	var present bool
+	var dot int
 
-	if fieldValues.Kind() != reflect.Struct {
-		return errors.New("unable to add query values from parameter: parameter is not a struct")
+	value, present = mapValue(values, name, foldCase)
+	if present {
+		return value, true
 	}
 
-	parameterType = fieldValues.Type()
-
-	for i := 0; i < fieldValues.NumField(); i++ {
+	dot = strings.IndexByte(name, '.')
+	if dot < 0 {
+		return nil, false
+	}
 
-		fieldValue = fieldValues.Field(i)
-		parameterField = parameterType.Field(i)
+	value, present = mapValue(values, name[:dot], foldCase)
+	if !present {
+		return nil, false
+	}
 
-		// public field?
-		visibilityCharacter, _ = utf8.DecodeRuneInString(parameterField.Name[0:])
+	values, present = value.(map[string]interface{})
+	if !present {
+		return nil, false
+	}
+	return lookupValue(values, name[dot+1:], foldCase)
+}
 
-		if fieldValue.CanSet() || unicode.IsUpper(visibilityCharacter) {
+/*
+	mapValue returns the value of [key] in [values]. If [foldCase] is true and there is no exact match,
+	the first key equal to [key] regardless of case is used.
+*/
+func mapValue(values map[string]interface{}, key string, foldCase bool) (interface{}, bool) {
 
-			// check to see if npq has a tag indicating a different query name
-			queryTag = parameterField.Tag.Get("sqlParameterName")
+	var value interface{}
+	var present bool
 
-			// otherwise just add the struct's name.
-			if len(queryTag) <= 0 {
-				queryTag = parameterField.Name
-			}
+	value, present = values[key]
+	if present || !foldCase {
+		return value, present
+	}
 
-			npq.SetValue(queryTag, fieldValue.Interface())
+	for candidate, value := range values {
+		if strings.EqualFold(candidate, key) {
+			return value, true
 		}
 	}
-	return nil
+	return nil, false
 }
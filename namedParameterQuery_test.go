@@ -1,7 +1,13 @@
 package namedParameterQuery
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 /*
@@ -37,8 +43,6 @@ type TestQueryParameter struct {
 
 func TestQueryParsing(test *testing.T) {
 
-	var query *NamedParameterQuery
-
 	// Each of these represents a single test.
 	queryParsingTests := []QueryParsingTest {
 		QueryParsingTest {
@@ -88,7 +92,7 @@ func TestQueryParsing(test *testing.T) {
 			Name: "ParametersInSubclause",
 		},
 		QueryParsingTest {
-			Input: "SELECT * FROM table WHERE col1 = :1234567890 AND col2 = :0987654321",
+			Input: "SELECT * FROM table WHERE col1 = :a1234567890 AND col2 = :_0987654321",
 			Expected: "SELECT * FROM table WHERE col1 = ? AND col2 = ?",
 			ExpectedParameters: 2,
 			Name: "NumericParameters",
@@ -107,10 +111,20 @@ func TestQueryParsing(test *testing.T) {
 		},
 	}
 
+	verifyQueryParsing(test, queryParsingTests, "?")
+}
+
+/*
+	Runs each of the given [parsingTests] against a query built with the given [argIndication] and [options].
+*/
+func verifyQueryParsing(test *testing.T, parsingTests []QueryParsingTest, argIndication string, options ...Option) {
+
+	var query *NamedParameterQuery
+
 	// Run each test.
-	for _, parsingTest := range queryParsingTests {
+	for _, parsingTest := range parsingTests {
 
-		query = NewNamedParameterQuery(parsingTest.Input, "?")
+		query = NewNamedParameterQuery(parsingTest.Input, argIndication, options...)
 
 		// test query texts
 		if(query.GetParsedQuery() != parsingTest.Expected) {
@@ -126,7 +140,7 @@ func TestQueryParsing(test *testing.T) {
 		}
 	}
 
-	test.Logf("Run %d query parsing tests", len(queryParsingTests))
+	test.Logf("Run %d query parsing tests", len(parsingTests))
 }
 
 /*
@@ -341,10 +355,10 @@ func TestStructParameters(test *testing.T) {
 }
 
 func verifyStructParameters(testName string, test *testing.T, query *NamedParameterQuery, expectedParameters []interface{}) {
+	verifyParameters(testName, test, query.GetParsedParameters(), expectedParameters)
+}
 
-	var actualParameters []interface{}
-
-	actualParameters = query.GetParsedParameters()
+func verifyParameters(testName string, test *testing.T, actualParameters []interface{}, expectedParameters []interface{}) {
 
 	actualParameterLength := len(actualParameters)
 	expectedParameterLength := len(expectedParameters)
@@ -363,3 +377,2189 @@ func verifyStructParameters(testName string, test *testing.T, query *NamedParame
 
 	test.Logf("Run %d struct reflection parameter tests", actualParameterLength)
 }
+
+func TestReset(test *testing.T) {
+
+	var query *NamedParameterQuery
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo", "?")
+	query.SetValue("foo", "something")
+	query.SetValue("bar", 15)
+	query.Reset()
+
+	verifyStructParameters("ResetClearsValues", test, query, []interface{} {
+		nil,
+		nil,
+		nil,
+	})
+
+	if(query.GetParsedQuery() != "SELECT * FROM table WHERE col1 = ? AND col2 = ? AND col3 = ?") {
+		test.Log("Test ResetKeepsQuery: Reset changed the parsed query. Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+
+	query.SetValue("foo", "else")
+
+	verifyStructParameters("ResetRebind", test, query, []interface{} {
+		"else",
+		nil,
+		"else",
+	})
+}
+
+func TestBracketIdentifiers(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	queryParsingTests := []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT [Order:Date] FROM table WHERE col1 = :foo",
+			Expected: "SELECT [Order:Date] FROM table WHERE col1 = ?",
+			ExpectedParameters: 1,
+			Name: "ColonInBrackets",
+		},
+		QueryParsingTest {
+			Input: "SELECT [Order]]:Date] FROM table WHERE col1 = :foo",
+			Expected: "SELECT [Order]]:Date] FROM table WHERE col1 = ?",
+			ExpectedParameters: 1,
+			Name: "EscapedBracket",
+		},
+		QueryParsingTest {
+			Input: "SELECT [a:b], ':c' FROM table WHERE col1 = '[' AND col2 = :foo",
+			Expected: "SELECT [a:b], ':c' FROM table WHERE col1 = '[' AND col2 = ?",
+			ExpectedParameters: 1,
+			Name: "BracketInString",
+		},
+	}
+
+	verifyQueryParsing(test, queryParsingTests, "?", WithBracketIdentifiers())
+
+	verifyQueryErrors(test, []QueryErrorTest {
+		QueryErrorTest {
+			Input: "SELECT [Order:Date FROM table WHERE col1 = :foo",
+			Name: "UnterminatedBracket",
+		},
+		QueryErrorTest {
+			Input: "SELECT [Order]] FROM table WHERE col1 = :foo",
+			Name: "UnterminatedEscapedBracket",
+		},
+	}, "?", WithBracketIdentifiers())
+
+	query, err = NewNamedParameterQueryForDialect("SELECT [order:date] FROM [order:table] WHERE [id] = :id AND [a]]:b] = :id", DialectSQLServer, WithBracketIdentifiers())
+	if(err != nil || query.GetParsedQuery() != "SELECT [order:date] FROM [order:table] WHERE [id] = @p1 AND [a]]:b] = @p1") {
+		test.Log("Test SQLServerBrackets: Actual: ", query.GetParsedQuery(), err)
+		test.Fail()
+	}
+
+	// without the option, brackets are not identifiers.
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT arr[:foo] FROM table",
+			Expected: "SELECT arr[?] FROM table",
+			ExpectedParameters: 1,
+			Name: "ArraySubscript",
+		},
+	}, "?")
+}
+
+func TestBind(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var parameters []interface{}
+	var err error
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo", "?")
+
+	parameters, err = query.Bind(map[string]interface{} {
+		"foo": "something",
+		"bar": 15,
+		"unused": true,
+	})
+	if(err != nil) {
+		test.Log("Test BindAllValues: unexpected error: ", err)
+		test.Fail()
+	}
+
+	verifyParameters("BindAllValues", test, parameters, []interface{} {
+		"something",
+		15,
+		"something",
+	})
+
+	// the query itself must not have been touched.
+	verifyStructParameters("BindDoesNotMutate", test, query, []interface{} {
+		nil,
+		nil,
+		nil,
+	})
+
+	_, err = query.Bind(map[string]interface{} {
+		"foo": "something",
+	})
+	if(err == nil) {
+		test.Log("Test BindMissingValue: expected an error for missing parameter 'bar'")
+		test.Fail()
+	}
+}
+
+/*
+	Represents a single test of a malformed query.
+	Parsing the given [Input] with ParseNamedParameterQuery must return an error.
+*/
+type QueryErrorTest struct {
+	Name string
+	Input string
+}
+
+func verifyQueryErrors(test *testing.T, errorTests []QueryErrorTest, argIndication string, options ...Option) {
+
+	var err error
+
+	for _, errorTest := range errorTests {
+
+		_, err = ParseNamedParameterQuery(errorTest.Input, argIndication, options...)
+
+		if(err == nil) {
+			test.Log("Test '", errorTest.Name, "': Expected a parse error, got none")
+			test.Fail()
+		}
+	}
+
+	test.Logf("Run %d query error tests", len(errorTests))
+}
+
+func TestDollarQuotedStrings(test *testing.T) {
+
+	queryParsingTests := []QueryParsingTest {
+		QueryParsingTest {
+			Input: "DO $$ BEGIN PERFORM :not_a_param; END $$; SELECT * FROM table WHERE col1 = :foo",
+			Expected: "DO $$ BEGIN PERFORM :not_a_param; END $$; SELECT * FROM table WHERE col1 = $1",
+			ExpectedParameters: 1,
+			Name: "EmptyTag",
+		},
+		QueryParsingTest {
+			Input: "SELECT $body$ a :b $body$, :foo",
+			Expected: "SELECT $body$ a :b $body$, $1",
+			ExpectedParameters: 1,
+			Name: "NamedTag",
+		},
+		QueryParsingTest {
+			Input: "SELECT $outer$ $inner$ :a $inner$ :b $outer$ FROM table WHERE col1 = :foo AND col2 = :bar",
+			Expected: "SELECT $outer$ $inner$ :a $inner$ :b $outer$ FROM table WHERE col1 = $1 AND col2 = $2",
+			ExpectedParameters: 2,
+			Name: "NestedTags",
+		},
+		QueryParsingTest {
+			Input: "SELECT col$1, $1 FROM table WHERE col1 = :foo",
+			Expected: "SELECT col$1, $1 FROM table WHERE col1 = $1",
+			ExpectedParameters: 1,
+			Name: "NotDollarQuotes",
+		},
+	}
+
+	verifyQueryParsing(test, queryParsingTests, "$")
+
+	verifyQueryErrors(test, []QueryErrorTest {
+		QueryErrorTest {
+			Input: "DO $$ BEGIN PERFORM :not_a_param; END",
+			Name: "UnterminatedEmptyTag",
+		},
+		QueryErrorTest {
+			Input: "SELECT $a$ :foo $b$",
+			Name: "UnterminatedNamedTag",
+		},
+	}, "$")
+}
+
+func TestParameterPrefixes(test *testing.T) {
+
+	var query *NamedParameterQuery
+
+	queryParsingTests := []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = @foo AND col2 = :bar",
+			Expected: "SELECT * FROM table WHERE col1 = ? AND col2 = ?",
+			ExpectedParameters: 2,
+			Name: "MixedPrefixes",
+		},
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = '@foo' AND col2 = @bar",
+			Expected: "SELECT * FROM table WHERE col1 = '@foo' AND col2 = ?",
+			ExpectedParameters: 1,
+			Name: "PrefixInQuotes",
+		},
+	}
+
+	verifyQueryParsing(test, queryParsingTests, "?", WithParameterPrefixes(':', '@'))
+
+	// the default prefix stays the colon alone.
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = @foo AND col2 = :bar",
+			Expected: "SELECT * FROM table WHERE col1 = @foo AND col2 = ?",
+			ExpectedParameters: 1,
+			Name: "DefaultPrefix",
+		},
+	}, "?")
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = @foo AND col2 = :foo", "?", WithParameterPrefixes(':', '@'))
+	query.SetValue("foo", "bar")
+
+	verifyStructParameters("SameNameAcrossPrefixes", test, query, []interface{} {
+		"bar",
+		"bar",
+	})
+}
+
+func TestUnterminatedStringLiteral(test *testing.T) {
+
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = :foo AND col2 = 'abc",
+			Expected: "SELECT * FROM table WHERE col1 = ? AND col2 = 'abc",
+			ExpectedParameters: 1,
+			Name: "UnterminatedLiteral",
+		},
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = '",
+			Expected: "SELECT * FROM table WHERE col1 = '",
+			Name: "TrailingQuote",
+		},
+	}, "?")
+
+	verifyQueryErrors(test, []QueryErrorTest {
+		QueryErrorTest {
+			Input: "SELECT * FROM table WHERE name = 'abc",
+			Name: "UnterminatedLiteral",
+		},
+		QueryErrorTest {
+			Input: "SELECT * FROM table WHERE name = 'it''s",
+			Name: "UnterminatedAfterEscape",
+		},
+	}, "?")
+}
+
+func TestGetParameterNames(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var names []string
+	var expected []string
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo AND col4 IN(:baz, :bar)", "?")
+	names = query.GetParameterNames()
+	expected = []string{"foo", "bar", "baz"}
+
+	if(len(names) != len(expected)) {
+		test.Log("Test ParameterNames: Expected ", expected, ", Actual: ", names)
+		test.FailNow()
+	}
+
+	for index, name := range names {
+		if(name != expected[index]) {
+			test.Log("Test ParameterNames: Expected ", expected, ", Actual: ", names)
+			test.Fail()
+		}
+	}
+
+	if(query.GetParameterCount() != 5) {
+		test.Log("Test ParameterCount: Expected 5 positional parameters, Actual: ", query.GetParameterCount())
+		test.Fail()
+	}
+
+	// the order doesn't depend on map iteration.
+	for i := 0; i < 20; i++ {
+
+		names = NewNamedParameterQuery("SELECT :e, :d, :c, :b, :a, :f, :g, :h", "?").GetParameterNames()
+		if(strings.Join(names, ",") != "e,d,c,b,a,f,g,h") {
+			test.Log("Test StableParameterNames: Actual: ", names)
+			test.Fail()
+		}
+	}
+
+	query = NewNamedParameterQuery("SELECT * FROM table", "?")
+
+	if(len(query.GetParameterNames()) != 0) {
+		test.Log("Test NoParameterNames: Expected no names, Actual: ", query.GetParameterNames())
+		test.Fail()
+	}
+}
+
+func TestDottedParameterNames(test *testing.T) {
+
+	var query *NamedParameterQuery
+
+	queryParsingTests := []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = :user.id AND col2 = :address.city",
+			Expected: "SELECT * FROM table WHERE col1 = ? AND col2 = ?",
+			ExpectedParameters: 2,
+			Name: "DottedNames",
+		},
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = :user. AND col2 = :.foo",
+			Expected: "SELECT * FROM table WHERE col1 = ?. AND col2 = :.foo",
+			ExpectedParameters: 1,
+			Name: "LeadingAndTrailingDots",
+		},
+	}
+
+	verifyQueryParsing(test, queryParsingTests, "?")
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :user.id AND col2 = :address.city AND col3 = :flat.key", "?")
+	query.SetValuesFromMap(map[string]interface{} {
+		"user": map[string]interface{} {
+			"id": 7,
+		},
+		"address": map[string]interface{} {
+			"city": "Paris",
+		},
+		"flat.key": "flat",
+	})
+
+	verifyStructParameters("NestedMapValues", test, query, []interface{} {
+		7,
+		"Paris",
+		"flat",
+	})
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :user.id", "?")
+	query.SetValue("user.id", 8)
+
+	verifyStructParameters("DottedSetValue", test, query, []interface{} {
+		8,
+	})
+}
+
+func TestGetParsedQueryAndParameters(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var queryText string
+	var parameters []interface{}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar", "$")
+	query.SetValue("foo", "something")
+	query.SetValue("bar", 15)
+
+	queryText, parameters = query.GetParsedQueryAndParameters()
+
+	if(queryText != "SELECT * FROM table WHERE col1 = $1 AND col2 = $2") {
+		test.Log("Test QueryAndParameters: Expected query text did not match actual parsed output")
+		test.Log("Actual: ", queryText)
+		test.Fail()
+	}
+
+	verifyParameters("QueryAndParameters", test, parameters, []interface{} {
+		"something",
+		15,
+	})
+}
+
+func TestEmptyParameterName(test *testing.T) {
+
+	queryParsingTests := []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = : AND col2 = :foo",
+			Expected: "SELECT * FROM table WHERE col1 = : AND col2 = ?",
+			ExpectedParameters: 1,
+			Name: "BareColon",
+		},
+		QueryParsingTest {
+			Input: "SELECT col1::int FROM table WHERE col2 = :foo::text",
+			Expected: "SELECT col1::int FROM table WHERE col2 = ?::text",
+			ExpectedParameters: 1,
+			Name: "Cast",
+		},
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = :",
+			Expected: "SELECT * FROM table WHERE col1 = :",
+			Name: "TrailingColon",
+		},
+	}
+
+	verifyQueryParsing(test, queryParsingTests, "?")
+
+	verifyQueryErrors(test, []QueryErrorTest {
+		QueryErrorTest {
+			Input: "SELECT * FROM table WHERE col1 = : b",
+			Name: "BareColon",
+		},
+		QueryErrorTest {
+			Input: "SELECT * FROM table WHERE col1 = :",
+			Name: "TrailingColon",
+		},
+	}, "?")
+
+	_, err := ParseNamedParameterQuery("SELECT col1::int FROM table WHERE col2 = :foo", "?")
+	if(err != nil) {
+		test.Log("Test CastIsNotAnError: unexpected error: ", err)
+		test.Fail()
+	}
+}
+
+func TestAssignmentOperator(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	queryText := "SELECT @row := @row + 1, t.* FROM t, (SELECT @row := 0) r WHERE id = :id AND @total:=@total + 1"
+
+	query, err = ParseNamedParameterQuery(queryText, "?")
+	if(err != nil) {
+		test.Log("Test AssignmentOperator: unexpected error: ", err)
+		test.FailNow()
+	}
+
+	if(query.GetParsedQuery() != "SELECT @row := @row + 1, t.* FROM t, (SELECT @row := 0) r WHERE id = ? AND @total:=@total + 1") {
+		test.Log("Test AssignmentOperator: Expected query text did not match actual parsed output")
+		test.Log("Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+
+	query.SetValue("id", 3)
+
+	verifyStructParameters("AssignmentOperator", test, query, []interface{} {
+		3,
+	})
+}
+
+func TestParseErrorOffset(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	query, err = ParseNamedParameterQuery("SELECT * FROM table WHERE x = : AND y = 1", "?")
+
+	if(query != nil || err == nil) {
+		test.Log("Test EmptyNameOffset: Expected a parse error and no query")
+		test.FailNow()
+	}
+
+	if(!strings.Contains(err.Error(), "offset 30")) {
+		test.Log("Test EmptyNameOffset: Expected the error to name offset 30, Actual: ", err)
+		test.Fail()
+	}
+
+	// the lenient constructor still returns a query.
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE x = : AND y = :y", "?")
+
+	if(len(query.GetParameterNames()) != 1) {
+		test.Log("Test EmptyNameIgnored: Expected only 'y' to be registered, Actual: ", query.GetParameterNames())
+		test.Fail()
+	}
+}
+
+func TestNumericParameterNames(test *testing.T) {
+
+	queryParsingTests := []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT arr[1:3] FROM table WHERE id = :id",
+			Expected: "SELECT arr[1:3] FROM table WHERE id = $1",
+			ExpectedParameters: 1,
+			Name: "ArraySlice",
+		},
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = :1 AND col2 = :snake_case",
+			Expected: "SELECT * FROM table WHERE col1 = :1 AND col2 = $1",
+			ExpectedParameters: 1,
+			Name: "LeadingDigit",
+		},
+	}
+
+	verifyQueryParsing(test, queryParsingTests, "$")
+
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = :1234567890 AND col2 = :0987654321",
+			Expected: "SELECT * FROM table WHERE col1 = $1 AND col2 = $2",
+			ExpectedParameters: 2,
+			Name: "NumericNamesOption",
+		},
+	}, "$", WithNumericParameterNames())
+
+	_, err := ParseNamedParameterQuery("SELECT arr[1:3] FROM table WHERE id = :id", "$")
+	if(err != nil) {
+		test.Log("Test ArraySliceIsNotAnError: unexpected error: ", err)
+		test.Fail()
+	}
+}
+
+func TestGetParameterOffsets(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var offsets map[string][]int
+
+	queryText := "SELECT * FROM table WHERE col1 = :foo AND col2 = 'é:x' AND col3 = :bar AND col4 = :foo"
+	query = NewNamedParameterQuery(queryText, "?")
+	offsets = query.GetParameterOffsets()
+
+	// offsets count runes, so "é" counts once although it is two bytes.
+	expected := map[string][]int {
+		"foo": []int{33, 82},
+		"bar": []int{66},
+	}
+
+	if(len(offsets) != len(expected)) {
+		test.Log("Test ParameterOffsets: Expected ", expected, ", Actual: ", offsets)
+		test.FailNow()
+	}
+
+	for name, expectedOffsets := range expected {
+
+		if(len(offsets[name]) != len(expectedOffsets)) {
+			test.Log("Test ParameterOffsets: Expected ", expected, ", Actual: ", offsets)
+			test.Fail()
+			continue
+		}
+
+		for index, offset := range expectedOffsets {
+			if(offsets[name][index] != offset || !strings.HasPrefix(string([]rune(queryText)[offset:]), ":" + name)) {
+				test.Log("Test ParameterOffsets: Expected ", expected, ", Actual: ", offsets)
+				test.Fail()
+			}
+		}
+	}
+}
+
+func TestGetPositions(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var positions map[string][]int
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo", "$")
+	positions = query.GetPositions()
+
+	if(len(positions) != 2 || fmt.Sprint(positions["foo"]) != "[0 2]" || fmt.Sprint(positions["bar"]) != "[1]") {
+		test.Log("Test Positions: Actual: ", positions)
+		test.Fail()
+	}
+
+	// the map is a copy.
+	positions["foo"][0] = 5
+	delete(positions, "bar")
+
+	if(query.GetPositions()["foo"][0] != 0 || len(query.GetPositions()) != 2) {
+		test.Log("Test PositionsAreCopied: Actual: ", query.GetPositions())
+		test.Fail()
+	}
+
+	query.SetValue("foo", 1)
+	if(query.GetParsedParameters()[2] != 1) {
+		test.Log("Test PositionsAreKept: Actual: ", query.GetParsedParameters())
+		test.Fail()
+	}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo", "?N")
+	if(fmt.Sprint(query.GetPositions()["foo"]) != "[0]") {
+		test.Log("Test ReusedPositions: Actual: ", query.GetPositions())
+		test.Fail()
+	}
+}
+
+func TestGetPositionalNames(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var names []string
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :Bar AND col3 = :foo", "$", WithCaseInsensitiveNames())
+	names = query.GetPositionalNames()
+
+	if(strings.Join(names, ",") != "foo,bar,foo" || len(names) != len(query.GetParsedParameters())) {
+		test.Log("Test PositionalNames: Actual: ", names)
+		test.Fail()
+	}
+
+	names[0] = "changed"
+	if(query.GetPositionalNames()[0] != "foo") {
+		test.Log("Test PositionalNamesAreCopied: Actual: ", query.GetPositionalNames())
+		test.Fail()
+	}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo", "?N")
+	if(strings.Join(query.GetPositionalNames(), ",") != "foo,bar") {
+		test.Log("Test ReusedPositionalNames: Actual: ", query.GetPositionalNames())
+		test.Fail()
+	}
+
+	if(NewNamedParameterQuery("SELECT 1", "?").GetPositionalNames() == nil) {
+		test.Log("Test NoPositionalNames: Expected an empty slice")
+		test.Fail()
+	}
+}
+
+func TestEscapedPrefix(test *testing.T) {
+
+	var query *NamedParameterQuery
+
+	queryParsingTests := []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT '\\:foo' FROM table WHERE col1 = \\:literal AND col2 = :foo",
+			Expected: "SELECT '\\:foo' FROM table WHERE col1 = :literal AND col2 = $1",
+			ExpectedParameters: 1,
+			Name: "EscapedAndUnescaped",
+		},
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = \\:a:b AND col2 = \\x",
+			Expected: "SELECT * FROM table WHERE col1 = :a$1 AND col2 = \\x",
+			ExpectedParameters: 1,
+			Name: "EscapeFollowedByParameter",
+		},
+	}
+
+	verifyQueryParsing(test, queryParsingTests, "$")
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = \\:foo AND col2 = :foo", "?")
+
+	if(len(query.GetParameterOffsets()["foo"]) != 1) {
+		test.Log("Test EscapedNotRegistered: Expected one occurrence of 'foo', Actual: ", query.GetParameterOffsets())
+		test.Fail()
+	}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = \\:foo", "?")
+
+	if(query.GetParsedQuery() != "SELECT * FROM table WHERE col1 = :foo" || query.GetParameterCount() != 0) {
+		test.Log("Test EscapedOnly: Expected a literal ':foo' and no parameter, Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+}
+
+func TestDoubledPrefixEscape(test *testing.T) {
+
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE data @? '$.a ? (@ == \"10::30\")' AND time = '10::30' AND path = ::foo AND col2 = :foo",
+			Expected: "SELECT * FROM table WHERE data @? '$.a ? (@ == \"10::30\")' AND time = '10::30' AND path = :foo AND col2 = $1",
+			ExpectedParameters: 1,
+			Name: "DoubledPrefixEscape",
+		},
+		QueryParsingTest {
+			Input: "SELECT 10::30, \\:bar, :foo",
+			Expected: "SELECT 10:30, :bar, $1",
+			ExpectedParameters: 1,
+			Name: "BothEscapes",
+		},
+	}, "$", WithDoubledPrefixEscape())
+}
+
+func TestBacktickIdentifiers(test *testing.T) {
+
+	queryParsingTests := []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT `col:umn` FROM t WHERE id = :id",
+			Expected: "SELECT `col:umn` FROM t WHERE id = ?",
+			ExpectedParameters: 1,
+			Name: "ColonInBackticks",
+		},
+		QueryParsingTest {
+			Input: "SELECT `a'b`, ':c' FROM `t:1` WHERE id = :id AND name = '`:x`'",
+			Expected: "SELECT `a'b`, ':c' FROM `t:1` WHERE id = ? AND name = '`:x`'",
+			ExpectedParameters: 1,
+			Name: "MixedQuotes",
+		},
+	}
+
+	verifyQueryParsing(test, queryParsingTests, "?")
+
+	verifyQueryErrors(test, []QueryErrorTest {
+		QueryErrorTest {
+			Input: "SELECT `col:umn FROM t WHERE id = :id",
+			Name: "UnterminatedBacktick",
+		},
+	}, "?")
+}
+
+func TestSetValues(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo", "?")
+
+	err = query.SetValues("foo", 1, "bar", "two", "unused", 3)
+	if(err != nil) {
+		test.Log("Test SetValuesPairs: unexpected error: ", err)
+		test.Fail()
+	}
+
+	verifyStructParameters("SetValuesPairs", test, query, []interface{} {
+		1,
+		"two",
+		1,
+	})
+
+	query.Reset()
+
+	if(query.SetValues("foo", 1, "bar") == nil) {
+		test.Log("Test SetValuesOddCount: expected an error for an odd number of arguments")
+		test.Fail()
+	}
+
+	if(query.SetValues("foo", 1, 2, "bar") == nil) {
+		test.Log("Test SetValuesNonStringName: expected an error for a non-string name")
+		test.Fail()
+	}
+
+	verifyStructParameters("SetValuesNothingSetOnError", test, query, []interface{} {
+		nil,
+		nil,
+		nil,
+	})
+}
+
+func TestMixedPositionalPlaceholders(test *testing.T) {
+
+	var err error
+
+	verifyQueryErrors(test, []QueryErrorTest {
+		QueryErrorTest {
+			Input: "SELECT * FROM table WHERE col1 = ? AND col2 = :foo",
+			Name: "PlaceholderBeforeParameter",
+		},
+		QueryErrorTest {
+			Input: "SELECT * FROM table WHERE col1 = :foo AND col2 = ?",
+			Name: "PlaceholderAfterParameter",
+		},
+	}, "?")
+
+	// a "?" inside a literal is not a placeholder.
+	_, err = ParseNamedParameterQuery("SELECT * FROM table WHERE col1 = '?' AND col2 = :foo", "?")
+	if(err != nil) {
+		test.Log("Test PlaceholderInLiteral: unexpected error: ", err)
+		test.Fail()
+	}
+
+	// nor is it with other output styles.
+	_, err = ParseNamedParameterQuery("SELECT * FROM table WHERE col1 ? 'key' AND col2 = :foo", "$")
+	if(err != nil) {
+		test.Log("Test PlaceholderWithDollarOutput: unexpected error: ", err)
+		test.Fail()
+	}
+}
+
+func TestEscapedQuestionMark(test *testing.T) {
+
+	var err error
+
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE data ?? :key AND data ??| :keys AND data ??& :all",
+			Expected: "SELECT * FROM table WHERE data ? ? AND data ?| ? AND data ?& ?",
+			ExpectedParameters: 3,
+			Name: "EscapedOperators",
+		},
+	}, "?")
+
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE data ? :key AND data ?| :keys AND data ?& :all",
+			Expected: "SELECT * FROM table WHERE data ? $1 AND data ?| $2 AND data ?& $3",
+			ExpectedParameters: 3,
+			Name: "UntouchedOperators",
+		},
+	}, "$")
+
+	_, err = ParseNamedParameterQuery("SELECT * FROM table WHERE data ??| :keys", "?")
+	if(err != nil) {
+		test.Log("Test EscapedOperatorIsNotAnError: unexpected error: ", err)
+		test.Fail()
+	}
+}
+
+func TestJSONOperators(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT data->'a'->>'b', data#>'{a,b}', data#>>'{a}' FROM table WHERE data ? :key AND data ?| :keys AND data->>'c' = :c",
+			Expected: "SELECT data->'a'->>'b', data#>'{a,b}', data#>>'{a}' FROM table WHERE data ? $1 AND data ?| $2 AND data->>'c' = $3",
+			ExpectedParameters: 3,
+			Name: "JSONOperatorsWithDollarOutput",
+		},
+	}, "$")
+
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT data->>'b' FROM table WHERE data ?? :key AND data#>>'{a}' = :a",
+			Expected: "SELECT data->>'b' FROM table WHERE data ? ? AND data#>>'{a}' = ?",
+			ExpectedParameters: 2,
+			Name: "JSONOperatorsWithQuestionMarkOutput",
+		},
+	}, "?")
+
+	_, err = ParseNamedParameterQuery("SELECT * FROM table WHERE data ? :key", "$")
+	if(err != nil) {
+		test.Log("Test ExistenceWithDollarOutput: unexpected error: ", err)
+		test.Fail()
+	}
+
+	_, err = ParseNamedParameterQuery("SELECT * FROM table WHERE data ? :key", "?")
+	if(err == nil) {
+		test.Log("Test ExistenceWithQuestionMarkOutput: Expected an error for the unescaped '?' operator")
+		test.Fail()
+	}
+
+	// the lenient constructor keeps the operator verbatim.
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE data ? :key", "?")
+	if(query.GetParsedQuery() != "SELECT * FROM table WHERE data ? ?" || query.GetParameterCount() != 1) {
+		test.Log("Test ExistenceKeptVerbatim: Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+}
+
+func TestExistingDollarPlaceholders(test *testing.T) {
+
+	var err error
+
+	verifyQueryErrors(test, []QueryErrorTest {
+		QueryErrorTest {
+			Input: "SELECT * FROM table WHERE col1 = $1 AND col2 = :foo",
+			Name: "MixedPlaceholders",
+		},
+		QueryErrorTest {
+			Input: "SELECT * FROM table WHERE col1 = :foo AND col2 = $12",
+			Name: "MixedPlaceholdersAfterParameter",
+		},
+	}, "$")
+
+	// "$1" inside literals, comments or identifiers is not a placeholder.
+	_, err = ParseNamedParameterQuery("SELECT col$1, '$1', $q$ $1 $q$ FROM table -- $1\n WHERE col1 = :foo", "$")
+	if(err != nil) {
+		test.Log("Test PlaceholderOutsideCode: unexpected error: ", err)
+		test.Fail()
+	}
+
+	// nor does it collide with other output styles.
+	_, err = ParseNamedParameterQuery("SELECT * FROM table WHERE col1 = $1 AND col2 = :foo", ":")
+	if(err != nil) {
+		test.Log("Test PlaceholderWithColonOutput: unexpected error: ", err)
+		test.Fail()
+	}
+}
+
+func TestNationalStringLiterals(test *testing.T) {
+
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = N'12:30' AND col2 = :foo",
+			Expected: "SELECT * FROM table WHERE col1 = N'12:30' AND col2 = ?",
+			ExpectedParameters: 1,
+			Name: "NationalString",
+		},
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = N'it''s :30' AND col2 = :foo",
+			Expected: "SELECT * FROM table WHERE col1 = N'it''s :30' AND col2 = ?",
+			ExpectedParameters: 1,
+			Name: "NationalStringDoubledQuote",
+		},
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = n'http://host:80/' AND col2 = :foo",
+			Expected: "SELECT * FROM table WHERE col1 = n'http://host:80/' AND col2 = ?",
+			ExpectedParameters: 1,
+			Name: "LowerCaseNationalString",
+		},
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = 'it''s :30' AND col2 = :foo",
+			Expected: "SELECT * FROM table WHERE col1 = 'it''s :30' AND col2 = ?",
+			ExpectedParameters: 1,
+			Name: "DoubledQuote",
+		},
+	}, "?")
+}
+
+func TestHashComments(test *testing.T) {
+
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table # runs at 10:30\nWHERE col1 = :foo",
+			Expected: "SELECT * FROM table # runs at 10:30\nWHERE col1 = ?",
+			ExpectedParameters: 1,
+			Name: "HashComment",
+		},
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = '#:notComment' AND col2 = :foo # :bar",
+			Expected: "SELECT * FROM table WHERE col1 = '#:notComment' AND col2 = ? # :bar",
+			ExpectedParameters: 1,
+			Name: "HashInLiteral",
+		},
+		QueryParsingTest {
+			Input: "SELECT `a#b` FROM table WHERE col1 = :foo",
+			Expected: "SELECT `a#b` FROM table WHERE col1 = ?",
+			ExpectedParameters: 1,
+			Name: "HashInIdentifier",
+		},
+	}, "?", WithHashComments())
+
+	// without the option, "#" is an operator.
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT col1 # :mask FROM table",
+			Expected: "SELECT col1 # $1 FROM table",
+			ExpectedParameters: 1,
+			Name: "HashOperator",
+		},
+	}, "$")
+}
+
+func TestClone(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var clone *NamedParameterQuery
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo", "$")
+	query.SetValue("foo", 1)
+
+	clone = query.Clone()
+
+	if(clone.GetParsedQuery() != query.GetParsedQuery()) {
+		test.Log("Test CloneQuery: Expected ", query.GetParsedQuery(), ", Actual: ", clone.GetParsedQuery())
+		test.Fail()
+	}
+
+	// the clone starts without values.
+	verifyStructParameters("CloneHasNoValues", test, clone, []interface{} {
+		nil,
+		nil,
+		nil,
+	})
+
+	clone.SetValue("foo", "clone")
+	clone.SetValue("bar", 2)
+
+	verifyStructParameters("CloneValues", test, clone, []interface{} {
+		"clone",
+		2,
+		"clone",
+	})
+
+	verifyStructParameters("OriginalUntouched", test, query, []interface{} {
+		1,
+		nil,
+		1,
+	})
+
+	if(query.IsValueSet("bar")) {
+		test.Log("Test OriginalNotSet: Expected 'bar' not to be set on the original query")
+		test.Fail()
+	}
+
+	// re-parsing the clone leaves the shared parsed pieces of the original alone.
+	clone.SetPlaceholderOffset(2)
+
+	if(clone.GetParsedQuery() != "SELECT * FROM table WHERE col1 = $3 AND col2 = $4 AND col3 = $5" ||
+		query.GetParsedQuery() != "SELECT * FROM table WHERE col1 = $1 AND col2 = $2 AND col3 = $3" ||
+		query.GetParameterOffsets()["foo"][1] != 65) {
+		test.Log("Test CloneReparsed: Actual: ", clone.GetParsedQuery(), ", original: ", query.GetParsedQuery())
+		test.Fail()
+	}
+}
+
+func TestString(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var expected string
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :baz", "$")
+	query.SetValue("foo", "it's")
+	query.SetValue("baz", nil)
+
+	expected = "SELECT * FROM table WHERE col1 = $1 AND col2 = $2 AND col3 = $3 [1: \"it's\", 2: <unset>, 3: <nil>]"
+
+	if(query.String() != expected) {
+		test.Log("Test String: Expected ", expected, ", Actual: ", query.String())
+		test.Fail()
+	}
+
+	// calling it has no side effect.
+	if(query.IsValueSet("bar") || query.String() != expected) {
+		test.Log("Test StringSideEffects: Expected the query to be untouched")
+		test.Fail()
+	}
+
+	// expanded slices are listed element by element, labelled as their placeholders are numbered.
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 IN (:ids) AND col2 = :name AND col3 = :bar", "$")
+	query.SetValue("ids", []int{1, 2})
+	query.SetValue("name", "foo")
+	query.SetPlaceholderOffset(5)
+
+	expected = "SELECT * FROM table WHERE col1 IN ($6, $7) AND col2 = $8 AND col3 = $9 [6: 1, 7: 2, 8: \"foo\", 9: <unset>]"
+
+	if(query.String() != expected) {
+		test.Log("Test StringExpanded: Expected ", expected, ", Actual: ", query.String())
+		test.Fail()
+	}
+
+	query = NewNamedParameterQuery("SELECT 1", "?")
+
+	if(query.String() != "SELECT 1 []") {
+		test.Log("Test StringNoParameters: Actual: ", query.String())
+		test.Fail()
+	}
+}
+
+func TestExtractParameterNames(test *testing.T) {
+
+	var names []string
+	var query *NamedParameterQuery
+
+	names = ExtractParameterNames("SELECT ':quoted', \":ident\" FROM table -- :comment\nWHERE col1 = :foo /* :block */ AND col2 = :bar AND col3 = :foo", "$")
+
+	if(strings.Join(names, ",") != "foo,bar") {
+		test.Log("Test ExtractParameterNames: Expected foo,bar, Actual: ", names)
+		test.Fail()
+	}
+
+	names = ExtractParameterNames("SELECT * FROM table WHERE col1 = @Foo AND col2 = :foo", "?", WithParameterPrefixes('@'), WithCaseInsensitiveNames())
+
+	if(strings.Join(names, ",") != "foo") {
+		test.Log("Test ExtractParameterNamesWithOptions: Expected foo, Actual: ", names)
+		test.Fail()
+	}
+
+	// only the names are kept, nothing to bind them.
+	query = newNamedParameterQuery(dialectOf("$"), nil)
+	query.namesOnly = true
+	query.setQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo")
+
+	if(strings.Join(query.GetParameterNames(), ",") != "foo,bar" || len(query.parameters) != 0 || len(query.placeholders) != 0 || len(query.revisedQuery) != 0) {
+		test.Log("Test ExtractParameterNamesOnly: Expected names only, Actual: ", query.GetParameterNames(), query.parameters, query.placeholders, query.revisedQuery)
+		test.Fail()
+	}
+
+	names = ExtractParameterNames("SELECT 1", "?")
+
+	if(len(names) != 0) {
+		test.Log("Test ExtractNoParameterNames: Expected no names, Actual: ", names)
+		test.Fail()
+	}
+}
+
+func TestParameterNameRunes(test *testing.T) {
+
+	withDots := func(character rune) bool {
+		return unicode.IsLetter(character) || unicode.IsDigit(character) || character == '_' || character == '.' || character == '-'
+	}
+
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = :user..id AND col2 = :page-size AND col3 = :v. AND arr[1:3] = 1",
+			Expected: "SELECT * FROM table WHERE col1 = $1 AND col2 = $2 AND col3 = $3 AND arr[1:3] = 1",
+			ExpectedParameters: 3,
+			Name: "CustomNameRunes",
+		},
+	}, "$", WithParameterNameRunes(withDots))
+
+	// a nil validator keeps the default rules.
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = :page-size",
+			Expected: "SELECT * FROM table WHERE col1 = $1-size",
+			ExpectedParameters: 1,
+			Name: "NilNameRunes",
+		},
+	}, "$", WithParameterNameRunes(nil))
+
+	if(strings.Join(ExtractParameterNames("SELECT :user..id, :page-size", "$", WithParameterNameRunes(withDots)), ",") != "user..id,page-size") {
+		test.Log("Test CustomNameRunesNames: Actual: ", ExtractParameterNames("SELECT :user..id, :page-size", "$", WithParameterNameRunes(withDots)))
+		test.Fail()
+	}
+}
+
+func TestHasParameter(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var queryText string
+
+	queryText = "SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo"
+	query = NewNamedParameterQuery(queryText, "$")
+
+	if(!query.HasParameter("foo") || !query.HasParameter("bar") || query.HasParameter("Foo") || query.HasParameter("baz")) {
+		test.Log("Test HasParameter: Expected only 'foo' and 'bar'")
+		test.Fail()
+	}
+
+	if(query.GetParameterOccurrences("foo") != 2 || query.GetParameterOccurrences("bar") != 1 || query.GetParameterOccurrences("baz") != 0) {
+		test.Log("Test ParameterOccurrences: Actual: ", query.GetParameterOccurrences("foo"), query.GetParameterOccurrences("bar"), query.GetParameterOccurrences("baz"))
+		test.Fail()
+	}
+
+	query = NewNamedParameterQuery(queryText, "$", WithCaseInsensitiveNames())
+
+	if(!query.HasParameter("FOO") || query.GetParameterOccurrences("Foo") != 2) {
+		test.Log("Test CaseInsensitiveHasParameter: Expected 'FOO' to match 'foo'")
+		test.Fail()
+	}
+
+	query = NewNamedParameterQuery(queryText, "$", WithReusedPlaceholders())
+
+	if(query.GetParameterOccurrences("foo") != 1) {
+		test.Log("Test ReusedParameterOccurrences: Expected 1, Actual: ", query.GetParameterOccurrences("foo"))
+		test.Fail()
+	}
+}
+
+func TestMissingParameters(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :baz AND col4 = :foo", "?")
+
+	if(strings.Join(query.MissingParameters(), ",") != "foo,bar,baz") {
+		test.Log("Test MissingParametersInitially: Actual: ", query.MissingParameters())
+		test.Fail()
+	}
+
+	// nil is a value, and one occurrence left unset is enough to be missing.
+	query.SetValue("bar", nil)
+	query.SetValueAt("foo", 0, 1)
+
+	if(strings.Join(query.MissingParameters(), ",") != "foo,baz") {
+		test.Log("Test MissingParametersPartiallySet: Actual: ", query.MissingParameters())
+		test.Fail()
+	}
+
+	err = query.Validate()
+	if(err == nil || !strings.Contains(err.Error(), "'foo', 'baz'")) {
+		test.Log("Test ValidateMissing: Actual error: ", err)
+		test.Fail()
+	}
+
+	query.SetValue("foo", 1)
+	query.SetValue("baz", 2)
+
+	if(query.MissingParameters() != nil || query.Validate() != nil) {
+		test.Log("Test ValidateAllSet: Actual: ", query.MissingParameters(), query.Validate())
+		test.Fail()
+	}
+
+	query.Reset()
+
+	if(query.Validate() == nil) {
+		test.Log("Test ValidateAfterReset: Expected an error")
+		test.Fail()
+	}
+
+	if(NewNamedParameterQuery("SELECT 1", "?").Validate() != nil) {
+		test.Log("Test ValidateWithoutParameters: Expected no error")
+		test.Fail()
+	}
+}
+
+func TestAdjacentParameters(test *testing.T) {
+
+	var query *NamedParameterQuery
+
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 IN(:a,:b,:c)",
+			Expected: "SELECT * FROM table WHERE col1 IN($1,$2,$3)",
+			ExpectedParameters: 3,
+			Name: "CommaSeparated",
+		},
+		QueryParsingTest {
+			Input: "SELECT :a:b FROM table",
+			Expected: "SELECT $1$2 FROM table",
+			ExpectedParameters: 2,
+			Name: "AdjacentPrefixes",
+		},
+		QueryParsingTest {
+			Input: "SELECT :a::int, :b::text FROM table",
+			Expected: "SELECT $1::int, $2::text FROM table",
+			ExpectedParameters: 2,
+			Name: "AdjacentCast",
+		},
+	}, "$")
+
+	query = NewNamedParameterQuery("SELECT :a:b, :a,:b FROM table", "?")
+	query.SetValue("a", 1)
+	query.SetValue("b", 2)
+
+	if(strings.Join(query.GetParameterNames(), ",") != "a,b") {
+		test.Log("Test AdjacentParameterNames: Actual: ", query.GetParameterNames())
+		test.Fail()
+	}
+	verifyParameters("AdjacentParameterValues", test, query.GetParsedParameters(), []interface{} {1, 2, 1, 2})
+}
+
+func TestClearValues(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var parameters []interface{}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo", "$")
+	query.SetValue("foo", 1)
+	query.SetValue("bar", 2)
+	parameters = query.GetParsedParameters()
+
+	query.ClearValues()
+
+	verifyParameters("ClearedValues", test, query.GetParsedParameters(), []interface{} {nil, nil, nil})
+
+	if(query.IsValueSet("foo") || query.IsValueSet("bar") || len(query.MissingParameters()) != 2) {
+		test.Log("Test ClearedValuesAreUnset: Actual: ", query.String())
+		test.Fail()
+	}
+
+	// the storage is reused, not reallocated.
+	if(parameters[0] != nil || &parameters[0] != &query.GetParsedParameters()[0]) {
+		test.Log("Test ClearedInPlace: Expected the same storage")
+		test.Fail()
+	}
+
+	query.SetDefault("default")
+	query.SetValue("bar", 2)
+	query.ClearValues()
+
+	verifyParameters("ClearedToDefault", test, query.GetParsedParameters(), []interface{} {"default", "default", "default"})
+}
+
+func TestSetDefault(test *testing.T) {
+
+	var query *NamedParameterQuery
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :baz AND col4 = :foo", "$")
+	query.SetValue("bar", nil)
+	query.SetValueAt("foo", 1, 1)
+	query.SetDefault("default")
+
+	verifyParameters("DefaultFillsUnset", test, query.GetParsedParameters(), []interface{} {"default", nil, "default", 1})
+
+	query.SetValue("baz", 2)
+	verifyParameters("DefaultOverridden", test, query.GetParsedParameters(), []interface{} {"default", nil, 2, 1})
+
+	if(query.IsValueSet("foo") || query.Validate() != nil) {
+		test.Log("Test DefaultIsNotSet: Expected 'foo' to be unset but not missing")
+		test.Fail()
+	}
+
+	verifyParameters("DefaultClone", test, query.Clone().GetParsedParameters(), []interface{} {"default", "default", "default", "default"})
+
+	query.Reset()
+	verifyParameters("DefaultAfterReset", test, query.GetParsedParameters(), []interface{} {"default", "default", "default", "default"})
+
+	// a nil default binds unset parameters as NULL on purpose, which GetNamedParameters no longer omits.
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar", ":", WithUnsetParametersOmitted())
+	query.SetValue("foo", 1)
+
+	if(len(query.GetNamedParameters()) != 1) {
+		test.Log("Test OmittedWithoutDefault: Actual: ", query.GetNamedParameters())
+		test.Fail()
+	}
+
+	query.SetDefault(nil)
+
+	if(len(query.GetNamedParameters()) != 2 || query.GetPgxNamedArgs()["bar"] != nil) {
+		test.Log("Test NilDefault: Actual: ", query.GetNamedParameters())
+		test.Fail()
+	}
+}
+
+func TestCollapsedWhitespace(test *testing.T) {
+
+	var query *NamedParameterQuery
+
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "\n\tSELECT *\n\tFROM table\n\tWHERE col1 = :foo\n\t\tAND col2 = 'a  \n  b'\n",
+			Expected: "SELECT * FROM table WHERE col1 = $1 AND col2 = 'a  \n  b'",
+			ExpectedParameters: 1,
+			Name: "CollapsedLines",
+		},
+		QueryParsingTest {
+			Input: "SELECT \"two  spaces\",   $$a   b$$ FROM table /* a   comment */   WHERE col1 = :foo   AND col2 = :bar",
+			Expected: "SELECT \"two  spaces\", $$a   b$$ FROM table /* a   comment */ WHERE col1 = $1 AND col2 = $2",
+			ExpectedParameters: 2,
+			Name: "CollapsedKeepsLiterals",
+		},
+		QueryParsingTest {
+			Input: "SELECT *   -- all   columns\n    FROM table\n    WHERE col1 = :foo -- last",
+			Expected: "SELECT * -- all   columns\nFROM table WHERE col1 = $1 -- last",
+			ExpectedParameters: 1,
+			Name: "CollapsedLineComments",
+		},
+	}, "$", WithCollapsedWhitespace())
+
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT *\n\tFROM table\n\tWHERE col1 = :foo\n",
+			Expected: "SELECT *\n\tFROM table\n\tWHERE col1 = $1\n",
+			ExpectedParameters: 1,
+			Name: "WhitespaceKeptByDefault",
+		},
+	}, "$")
+
+	query = NewNamedParameterQuery("SELECT *\n  FROM table\n  WHERE col1 IN (:ids)\n  AND col2 = :foo\n", "$", WithCollapsedWhitespace())
+	query.SetValue("ids", []int{1, 2})
+	query.SetValue("foo", 3)
+
+	if(query.GetParsedQuery() != "SELECT * FROM table WHERE col1 IN ($1, $2) AND col2 = $3" || query.GetParameterOffsets()["foo"][0] != 58) {
+		test.Log("Test CollapsedExpansion: Actual: ", query.GetParsedQuery(), query.GetParameterOffsets())
+		test.Fail()
+	}
+}
+
+func TestPlainTextQuery(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT foo, bar FROM baz WHERE quux = 1 ORDER BY grault",
+			Expected: "SELECT foo, bar FROM baz WHERE quux = 1 ORDER BY grault",
+			Name: "PlainText",
+		},
+		QueryParsingTest {
+			Input: "",
+			Expected: "",
+			Name: "EmptyText",
+		},
+	}, "$")
+
+	query = NewNamedParameterQuery("SELECT foo FROM bar", "?")
+	if(query.GetParsedParameters() == nil || len(query.GetParsedParameters()) != 0 || query.Validate() != nil) {
+		test.Log("Test PlainTextParameters: Actual: ", query.GetParsedParameters())
+		test.Fail()
+	}
+
+	// other prefixes aren't plain text, nor are errors skipped.
+	if(NewNamedParameterQuery("SELECT foo FROM bar WHERE baz = @quux", "$", WithParameterPrefixes('@')).GetParsedQuery() != "SELECT foo FROM bar WHERE baz = $1") {
+		test.Log("Test PlainTextPrefix: Expected '@quux' to be a parameter")
+		test.Fail()
+	}
+
+	_, err = ParseNamedParameterQuery("SELECT foo FROM bar WHERE baz = 'quux", "?")
+	if(err == nil) {
+		test.Log("Test PlainTextError: Expected an unterminated literal error")
+		test.Fail()
+	}
+
+	if(NewNamedParameterQuery("SELECT foo\n  FROM bar", "?", WithCollapsedWhitespace()).GetParsedQuery() != "SELECT foo FROM bar") {
+		test.Log("Test PlainTextCollapsed: Expected whitespace to be collapsed")
+		test.Fail()
+	}
+}
+
+func TestGetParsedParametersChecked(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var parameters []interface{}
+	var err error
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :baz", "$")
+	query.SetValue("bar", nil)
+
+	parameters, err = query.GetParsedParametersChecked()
+	if(err == nil || parameters != nil || !strings.Contains(err.Error(), "'foo', 'baz'")) {
+		test.Log("Test CheckedMissing: Actual: ", parameters, err)
+		test.Fail()
+	}
+
+	query.SetValue("foo", 1)
+	query.SetValue("baz", []int{2, 3})
+
+	parameters, err = query.GetParsedParametersChecked()
+	if(err != nil) {
+		test.Log("Test CheckedComplete: unexpected error: ", err)
+		test.Fail()
+	}
+	verifyParameters("CheckedComplete", test, parameters, []interface{} {1, nil, 2, 3})
+}
+
+func TestUnicodeParameterNames(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var queryText string
+
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = :naïve AND col2 = :名前。 AND col3 = :café€ AND col4 = :日本語",
+			Expected: "SELECT * FROM table WHERE col1 = $1 AND col2 = $2。 AND col3 = $3€ AND col4 = $4",
+			ExpectedParameters: 4,
+			Name: "MultibyteNames",
+		},
+		QueryParsingTest {
+			Input: "SELECT :ñ,:ü,:é",
+			Expected: "SELECT $1,$2,$3",
+			ExpectedParameters: 3,
+			Name: "AdjacentMultibyteNames",
+		},
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = :nai\u0308ve AND col2 = \u0308",
+			Expected: "SELECT * FROM table WHERE col1 = $1 AND col2 = \u0308",
+			ExpectedParameters: 1,
+			Name: "CombiningMarks",
+		},
+	}, "$")
+
+	queryText = "SELECT * FROM table WHERE col1 = :naïve AND col2 = :名前。 AND col3 = :café€ AND col4 = :nai\u0308ve AND col5 = :naïve"
+	query = NewNamedParameterQuery(queryText, "?")
+
+	query.SetValue("naïve", 1)
+	query.SetValue("名前", 2)
+	query.SetValue("café", 3)
+	query.SetValue("nai\u0308ve", 4)
+
+	verifyStructParameters("MultibyteNameValues", test, query, []interface{} {1, 2, 3, 4, 1})
+
+	if(strings.Join(query.GetParameterNames(), ",") != "naïve,名前,café,nai\u0308ve" || query.GetParameterOffsets()["名前"][0] != utf8.RuneCountInString(queryText[:strings.Index(queryText, ":名前")])) {
+		test.Log("Test MultibyteNameCapture: Actual: ", query.GetParameterNames(), query.GetParameterOffsets())
+		test.Fail()
+	}
+}
+
+/*
+	The character which ends a parameter name is handled as any other, rather than copied as it is.
+*/
+func TestParameterTerminators(test *testing.T) {
+
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT :a'literal:c'",
+			Expected: "SELECT $1'literal:c'",
+			ExpectedParameters: 1,
+			Name: "QuoteTerminator",
+		},
+		QueryParsingTest {
+			Input: ":a:b",
+			Expected: "$1$2",
+			ExpectedParameters: 2,
+			Name: "PrefixTerminator",
+		},
+		QueryParsingTest {
+			Input: "SELECT f(:a(:b))",
+			Expected: "SELECT f($1($2))",
+			ExpectedParameters: 2,
+			Name: "ParenthesisTerminator",
+		},
+		QueryParsingTest {
+			Input: "SELECT :a\"id:x\"",
+			Expected: "SELECT $1\"id:x\"",
+			ExpectedParameters: 1,
+			Name: "IdentifierTerminator",
+		},
+		QueryParsingTest {
+			Input: "SELECT :a-- :c\n:b",
+			Expected: "SELECT $1-- :c\n$2",
+			ExpectedParameters: 2,
+			Name: "LineCommentTerminator",
+		},
+		QueryParsingTest {
+			Input: "SELECT :a/* :c */:b",
+			Expected: "SELECT $1/* :c */$2",
+			ExpectedParameters: 2,
+			Name: "BlockCommentTerminator",
+		},
+		QueryParsingTest {
+			Input: "SELECT :a;SELECT :b",
+			Expected: "SELECT $1;SELECT $2",
+			ExpectedParameters: 2,
+			Name: "SemicolonTerminator",
+		},
+		QueryParsingTest {
+			Input: "SELECT :a€:b",
+			Expected: "SELECT $1€$2",
+			ExpectedParameters: 2,
+			Name: "MultibyteTerminator",
+		},
+	}, "$")
+
+	if(len(NewNamedParameterQuery("SELECT :a;SELECT :b", "$").semicolons) != 1) {
+		test.Log("Test SemicolonAfterParameter: Expected the semicolon to end a statement")
+		test.Fail()
+	}
+}
+
+func TestChainedValues(test *testing.T) {
+
+	var query *NamedParameterQuery
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :baz", "?").
+		WithValue("foo", 1).
+		WithValuesFromMap(map[string]interface{} {
+			"bar": "two",
+		}).
+		WithValue("baz", 3.0)
+
+	verifyStructParameters("ChainedValues", test, query, []interface{} {
+		1,
+		"two",
+		3.0,
+	})
+}
+
+func TestChainedErrors(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var parameters []interface{}
+	var err error
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :baz", "?").
+		WithValues("foo", 1).
+		WithValuesFromJSON([]byte(`{"bar": 2}`)).
+		WithValueStrict("baz", 3)
+
+	if(query.Err() != nil) {
+		test.Log("Test ChainedNoError: unexpected error: ", query.Err())
+		test.Fail()
+	}
+	verifyParameters("ChainedNoError", test, query.GetParsedParameters(), []interface{} {1, int64(2), 3})
+
+	// the first error is kept, and the setters which follow do nothing.
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :baz", "?").
+		WithValue("foo", 1).
+		WithValuesFromStruct(42).
+		WithValueStrict("bar", map[string]int{}).
+		WithValue("baz", 3)
+
+	parameters, err = query.GetParsedParametersChecked()
+
+	if(!errors.Is(query.Err(), ErrNotAStruct) || err != query.Err() || parameters != nil || query.IsValueSet("baz")) {
+		test.Log("Test ChainedError: Expected ErrNotAStruct, Actual: ", query.Err(), err)
+		test.Fail()
+	}
+
+	if(query.Clone().Err() != nil) {
+		test.Log("Test ChainedErrorClone: Expected a clone without error")
+		test.Fail()
+	}
+
+	query.Reset()
+	query.WithValuesFromMap(map[string]interface{} {"foo": 1, "bar": 2, "baz": 3})
+
+	if(query.Err() != nil || query.Validate() != nil) {
+		test.Log("Test ChainedErrorReset: Expected Reset to clear the error, Actual: ", query.Err())
+		test.Fail()
+	}
+
+	// Set methods return their errors, without keeping them.
+	if(query.SetValues("foo") == nil || query.Err() != nil) {
+		test.Log("Test SetterErrorNotKept: Actual: ", query.Err())
+		test.Fail()
+	}
+}
+
+func TestParameterInUnterminatedLiteral(test *testing.T) {
+
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE id = :id AND name = 'unclosed :oops",
+			Expected: "SELECT * FROM table WHERE id = ? AND name = 'unclosed :oops",
+			ExpectedParameters: 1,
+			Name: "ParameterInUnterminatedLiteral",
+		},
+	}, "?")
+
+	verifyQueryErrors(test, []QueryErrorTest {
+		QueryErrorTest {
+			Input: "SELECT * FROM table WHERE name = 'unclosed :oops",
+			Name: "ParameterInUnterminatedLiteral",
+		},
+	}, "?")
+}
+
+func TestEndOfQuery(test *testing.T) {
+
+	var query *NamedParameterQuery
+
+	queryParsingTests := []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = :foo",
+			Expected: "SELECT * FROM table WHERE col1 = ?",
+			ExpectedParameters: 1,
+			Name: "EndsOnParameter",
+		},
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = :user.",
+			Expected: "SELECT * FROM table WHERE col1 = ?.",
+			ExpectedParameters: 1,
+			Name: "EndsOnDot",
+		},
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = ''",
+			Expected: "SELECT * FROM table WHERE col1 = ''",
+			Name: "EndsOnQuote",
+		},
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = :",
+			Expected: "SELECT * FROM table WHERE col1 = :",
+			Name: "EndsOnColon",
+		},
+		QueryParsingTest {
+			Input: ":",
+			Expected: ":",
+			Name: "LoneColon",
+		},
+	}
+
+	verifyQueryParsing(test, queryParsingTests, "?")
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo", "?")
+	query.SetValue("foo", "bar")
+
+	verifyStructParameters("EndsOnParameterValue", test, query, []interface{} {
+		"bar",
+	})
+}
+
+func TestEscapeStringLiterals(test *testing.T) {
+
+	queryParsingTests := []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE path = E'C:\\\\temp\\\\x' AND id = :id",
+			Expected: "SELECT * FROM table WHERE path = E'C:\\\\temp\\\\x' AND id = $1",
+			ExpectedParameters: 1,
+			Name: "EscapedBackslashes",
+		},
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE name = e'it\\'s :not' AND id = :id",
+			Expected: "SELECT * FROM table WHERE name = e'it\\'s :not' AND id = $1",
+			ExpectedParameters: 1,
+			Name: "EscapedQuote",
+		},
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE name = E'it''s \\' :not' AND id = :id",
+			Expected: "SELECT * FROM table WHERE name = E'it''s \\' :not' AND id = $1",
+			ExpectedParameters: 1,
+			Name: "DoubledQuote",
+		},
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE name = 'C:\\' AND id = :id",
+			Expected: "SELECT * FROM table WHERE name = 'C:\\' AND id = $1",
+			ExpectedParameters: 1,
+			Name: "PlainStringBackslash",
+		},
+		QueryParsingTest {
+			Input: "SELECT TYPE'x' FROM table WHERE id = :id",
+			Expected: "SELECT TYPE'x' FROM table WHERE id = $1",
+			ExpectedParameters: 1,
+			Name: "IdentifierEndingInE",
+		},
+	}
+
+	verifyQueryParsing(test, queryParsingTests, "$")
+
+	verifyQueryErrors(test, []QueryErrorTest {
+		QueryErrorTest {
+			Input: "SELECT * FROM table WHERE name = E'it\\' AND id = :id",
+			Name: "UnterminatedEscapeString",
+		},
+	}, "$")
+}
+
+func TestInvalidUTF8(test *testing.T) {
+
+	queryParsingTests := []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = 'caf\xe9' AND col2 = :foo AND col3 = \xff",
+			Expected: "SELECT * FROM table WHERE col1 = 'caf\xe9' AND col2 = ? AND col3 = \xff",
+			ExpectedParameters: 1,
+			Name: "InvalidBytesKept",
+		},
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = :foo\xe9",
+			Expected: "SELECT * FROM table WHERE col1 = ?\xe9",
+			ExpectedParameters: 1,
+			Name: "InvalidByteAfterParameter",
+		},
+		QueryParsingTest {
+			Input: "SELECT `t\xe9`, E'\xe9\\\xe9', [\xe9] FROM table WHERE col1 = :foo",
+			Expected: "SELECT `t\xe9`, E'\xe9\\\xe9', [\xe9] FROM table WHERE col1 = ?",
+			ExpectedParameters: 1,
+			Name: "InvalidBytesInQuotes",
+		},
+	}
+
+	verifyQueryParsing(test, queryParsingTests, "?", WithBracketIdentifiers())
+}
+
+func TestDollarQuotedFunctionBody(test *testing.T) {
+
+	var query *NamedParameterQuery
+
+	queryText := "CREATE FUNCTION f(a int) RETURNS text AS $fn$\n" +
+		"BEGIN\n" +
+		"\tRETURN format('%s:%s', :a, $1);\n" +
+		"END\n" +
+		"$fn$ LANGUAGE plpgsql; SELECT f(:value), :other::text"
+
+	query = NewNamedParameterQuery(queryText, "$")
+
+	expected := "CREATE FUNCTION f(a int) RETURNS text AS $fn$\n" +
+		"BEGIN\n" +
+		"\tRETURN format('%s:%s', :a, $1);\n" +
+		"END\n" +
+		"$fn$ LANGUAGE plpgsql; SELECT f($1), $2::text"
+
+	if(query.GetParsedQuery() != expected) {
+		test.Log("Test FunctionBody: Expected query text did not match actual parsed output")
+		test.Log("Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+
+	if(len(query.GetParameterNames()) != 2 || query.GetParameterNames()[0] != "value") {
+		test.Log("Test FunctionBody: Expected parameters 'value' and 'other', Actual: ", query.GetParameterNames())
+		test.Fail()
+	}
+}
+
+func TestParameterCounts(test *testing.T) {
+
+	var query *NamedParameterQuery
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo AND col2 = :bar AND col3 = :foo", "?")
+
+	if(query.GetParameterCount() != 3) {
+		test.Log("Test ParameterCount: Expected 3, Actual: ", query.GetParameterCount())
+		test.Fail()
+	}
+
+	if(query.GetDistinctParameterCount() != 2) {
+		test.Log("Test DistinctParameterCount: Expected 2, Actual: ", query.GetDistinctParameterCount())
+		test.Fail()
+	}
+}
+
+type CaseInsensitiveParameterTest struct {
+	USERID int
+}
+
+func TestCaseInsensitiveNames(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var spellings []string
+
+	queryText := "SELECT * FROM table WHERE col1 = :UserId AND col2 = :userId AND col3 = :userid AND col4 = :Name"
+
+	query = NewNamedParameterQuery(queryText, "?", WithCaseInsensitiveNames())
+	query.SetValue("USERID", 7)
+	query.SetValue("name", "foo")
+
+	verifyStructParameters("CaseInsensitiveSetValue", test, query, []interface{} {
+		7,
+		7,
+		7,
+		"foo",
+	})
+
+	query = NewNamedParameterQuery(queryText, "?", WithCaseInsensitiveNames())
+	query.SetValuesFromMap(map[string]interface{} {
+		"userID": 8,
+		"NAME": "bar",
+	})
+
+	verifyStructParameters("CaseInsensitiveMap", test, query, []interface{} {
+		8,
+		8,
+		8,
+		"bar",
+	})
+
+	query = NewNamedParameterQuery(queryText, "?", WithCaseInsensitiveNames())
+	query.SetValuesFromStruct(CaseInsensitiveParameterTest{USERID: 9})
+
+	verifyStructParameters("CaseInsensitiveStruct", test, query, []interface{} {
+		9,
+		9,
+		9,
+		nil,
+	})
+
+	spellings = query.GetParameterSpellings("USERID")
+
+	if(len(spellings) != 3 || spellings[0] != "UserId" || spellings[1] != "userId" || spellings[2] != "userid") {
+		test.Log("Test CaseInsensitiveSpellings: Expected [UserId userId userid], Actual: ", spellings)
+		test.Fail()
+	}
+
+	// names stay case-sensitive by default.
+	query = NewNamedParameterQuery(queryText, "?")
+	query.SetValue("userid", 7)
+
+	verifyStructParameters("CaseSensitiveByDefault", test, query, []interface{} {
+		nil,
+		nil,
+		7,
+		nil,
+	})
+}
+
+type CaseInsensitiveTagTest struct {
+	First string `sqlParameterName:"FIRSTNAME"`
+	Address struct {
+		City string `sqlParameterName:"City"`
+	} `sqlParameterName:"ADDR"`
+}
+
+type CaseInsensitiveDuplicateTest struct {
+	Name string
+	Other string `sqlParameterName:"name"`
+}
+
+func TestCaseInsensitiveBinding(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var parameters []interface{}
+	var err error
+
+	queryText := "SELECT * FROM table WHERE col1 = :FirstName AND col2 = :addr.city AND col3 = :firstname"
+
+	query = NewNamedParameterQuery(queryText, "?", WithCaseInsensitiveNames())
+	parameters = []interface{}{}
+	parameters, err = query.Bind(map[string]interface{} {
+		"FIRSTNAME": "alice",
+		"Addr": map[string]interface{} {"CITY": "Paris"},
+	})
+
+	if(err != nil) {
+		test.Log("Test CaseInsensitiveBind: unexpected error: ", err)
+		test.Fail()
+	}
+	verifyParameters("CaseInsensitiveBind", test, parameters, []interface{} {"alice", "Paris", "alice"})
+
+	var tagged CaseInsensitiveTagTest
+	tagged.First = "bob"
+	tagged.Address.City = "Lyon"
+
+	query = NewNamedParameterQuery(queryText, "?", WithCaseInsensitiveNames())
+	query.SetValuesFromStruct(tagged)
+
+	verifyStructParameters("CaseInsensitiveTags", test, query, []interface{} {
+		"bob",
+		"Lyon",
+		"bob",
+	})
+
+	if(!query.HasParameter("FIRSTNAME") || fmt.Sprint(query.GetParameterNames()) != "[firstname addr.city]") {
+		test.Log("Test CaseInsensitiveNormalizedNames: Actual: ", query.GetParameterNames())
+		test.Fail()
+	}
+
+	// names which only differ by case are the same parameter, so they collide.
+	query = NewNamedParameterQuery("SELECT :name", "?", WithCaseInsensitiveNames())
+	err = query.SetValuesFromStruct(CaseInsensitiveDuplicateTest{"a", "b"})
+
+	if(err == nil || query.IsValueSet("name")) {
+		test.Log("Test CaseInsensitiveDuplicates: Expected an error, Actual: ", err)
+		test.Fail()
+	}
+
+	if(NewNamedParameterQuery("SELECT :name", "?").SetValuesFromStruct(CaseInsensitiveDuplicateTest{"a", "b"}) != nil) {
+		test.Log("Test CaseSensitiveNoDuplicates: Expected no error by default")
+		test.Fail()
+	}
+}
+
+type AtParameterTest struct {
+	FirstName string `sqlParameterName:"firstName"`
+	LastName string `sqlParameterName:"lastName"`
+}
+
+func TestAtParameters(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	queryParsingTests := []QueryParsingTest {
+		QueryParsingTest {
+			Input: "UPDATE t SET a = @firstName WHERE b = @lastName; SELECT @@ROWCOUNT, @@IDENTITY",
+			Expected: "UPDATE t SET a = $1 WHERE b = $2; SELECT @@ROWCOUNT, @@IDENTITY",
+			ExpectedParameters: 2,
+			Name: "SystemVariables",
+		},
+		QueryParsingTest {
+			Input: "SELECT * FROM t WHERE a = @firstName AND b = ':x' AND c = '@y' AND d = :notParameter",
+			Expected: "SELECT * FROM t WHERE a = $1 AND b = ':x' AND c = '@y' AND d = :notParameter",
+			ExpectedParameters: 1,
+			Name: "AtOnly",
+		},
+	}
+
+	verifyQueryParsing(test, queryParsingTests, "$", WithParameterPrefixes('@'))
+
+	query, err = ParseNamedParameterQuery("SELECT @@ROWCOUNT WHERE a = @firstName OR a = @lastName OR b = @firstName", "?", WithParameterPrefixes('@'))
+	if(err != nil) {
+		test.Log("Test AtParameters: unexpected error: ", err)
+		test.FailNow()
+	}
+
+	query.SetValuesFromStruct(AtParameterTest{FirstName: "Alice", LastName: "Bob"})
+
+	verifyStructParameters("AtParametersFromStruct", test, query, []interface{} {
+		"Alice",
+		"Bob",
+		"Alice",
+	})
+}
+
+func TestSetValueAt(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 BETWEEN :range AND :range AND col2 = :foo", "?")
+	query.SetValue("range", 1)
+	query.SetValue("foo", "bar")
+
+	err = query.SetValueAt("range", 1, 10)
+	if(err != nil) {
+		test.Log("Test SetValueAt: unexpected error: ", err)
+		test.Fail()
+	}
+
+	verifyStructParameters("SetValueAt", test, query, []interface{} {
+		1,
+		10,
+		"bar",
+	})
+
+	if(query.SetValueAt("range", 2, 100) == nil || query.SetValueAt("range", -1, 100) == nil) {
+		test.Log("Test SetValueAtOutOfRange: expected an error for an out of range occurrence")
+		test.Fail()
+	}
+
+	if(query.SetValueAt("unknown", 0, 100) == nil) {
+		test.Log("Test SetValueAtUnknown: expected an error for an unknown parameter")
+		test.Fail()
+	}
+}
+
+func TestDollarParameters(test *testing.T) {
+
+	queryParsingTests := []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = $foo AND col2 = ${bar}baz AND col3 = $foo",
+			Expected: "SELECT * FROM table WHERE col1 = ? AND col2 = ?baz AND col3 = ?",
+			ExpectedParameters: 3,
+			Name: "DollarAndBraces",
+		},
+		QueryParsingTest {
+			Input: "SELECT $body$ $foo $body$, $$ ${foo} $$ FROM table WHERE col1 = $1 AND col2 = $foo",
+			Expected: "SELECT $body$ $foo $body$, $$ ${foo} $$ FROM table WHERE col1 = $1 AND col2 = ?",
+			ExpectedParameters: 1,
+			Name: "DollarQuotesAndPositional",
+		},
+		QueryParsingTest {
+			Input: "SELECT '$foo', col$1 FROM table WHERE col1 = :foo",
+			Expected: "SELECT '$foo', col$1 FROM table WHERE col1 = :foo",
+			Name: "NotParameters",
+		},
+	}
+
+	verifyQueryParsing(test, queryParsingTests, "?", WithParameterPrefixes('$'))
+
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = ${foo} AND col2 = :bar",
+			Expected: "SELECT * FROM table WHERE col1 = :foo AND col2 = :bar",
+			ExpectedParameters: 2,
+			Name: "NamedOutput",
+		},
+	}, ":", WithParameterPrefixes('$', ':'))
+
+	verifyQueryErrors(test, []QueryErrorTest {
+		QueryErrorTest {
+			Input: "SELECT * FROM table WHERE col1 = ${foo",
+			Name: "UnclosedBrace",
+		},
+		QueryErrorTest {
+			Input: "SELECT * FROM table WHERE col1 = ${}",
+			Name: "EmptyBraces",
+		},
+	}, "?", WithParameterPrefixes('$'))
+}
+
+func TestBraceParameters(test *testing.T) {
+
+	var query *NamedParameterQuery
+
+	queryParsingTests := []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = {userId} AND col2 = :name AND col3 = {userId}",
+			Expected: "SELECT * FROM table WHERE col1 = $1 AND col2 = $2 AND col3 = $3",
+			ExpectedParameters: 3,
+			Name: "BracesAndColons",
+		},
+		QueryParsingTest {
+			Input: "SELECT '{\"a\":1}', {{notParameter}}, {fn NOW()}, { spaced } FROM table WHERE col1 = {foo}",
+			Expected: "SELECT '{\"a\":1}', {notParameter}, {fn NOW()}, { spaced } FROM table WHERE col1 = $1",
+			ExpectedParameters: 1,
+			Name: "LiteralBraces",
+		},
+	}
+
+	verifyQueryParsing(test, queryParsingTests, "$", WithBraceParameters())
+
+	// without the option, braces are plain text.
+	verifyQueryParsing(test, []QueryParsingTest {
+		QueryParsingTest {
+			Input: "SELECT * FROM table WHERE col1 = {userId} AND col2 = {{x}}",
+			Expected: "SELECT * FROM table WHERE col1 = {userId} AND col2 = {{x}}",
+			Name: "DefaultBraces",
+		},
+	}, "$")
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = {userId} AND col2 = :userId", "?", WithBraceParameters())
+	query.SetValue("userId", 5)
+
+	verifyStructParameters("BraceParameterValues", test, query, []interface{} {
+		5,
+		5,
+	})
+}
+
+func TestInputMarkers(test *testing.T) {
+
+	var err error
+
+	for _, prefix := range []rune{':', '@', '$'} {
+
+		marker := string(prefix)
+
+		queryParsingTests := []QueryParsingTest {
+			QueryParsingTest {
+				Input: "SELECT '" + marker + "a', \"" + marker + "b\" FROM table WHERE col1 = " + marker + "foo",
+				Expected: "SELECT '" + marker + "a', \"" + marker + "b\" FROM table WHERE col1 = ?",
+				ExpectedParameters: 1,
+				Name: "MarkerInQuotes" + marker,
+			},
+			QueryParsingTest {
+				Input: "SELECT * FROM table -- col1 = " + marker + "a\nWHERE col1 = " + marker + "foo /* AND col2 = " + marker + "b */",
+				Expected: "SELECT * FROM table -- col1 = " + marker + "a\nWHERE col1 = ? /* AND col2 = " + marker + "b */",
+				ExpectedParameters: 1,
+				Name: "MarkerInComments" + marker,
+			},
+		}
+
+		verifyQueryParsing(test, queryParsingTests, "?", WithParameterPrefixes(prefix))
+	}
+
+	verifyQueryErrors(test, []QueryErrorTest {
+		QueryErrorTest {
+			Input: "SELECT * FROM table /* WHERE col1 = :foo",
+			Name: "UnterminatedComment",
+		},
+	}, "?")
+
+	_, err = ParseNamedParameterQuery("SELECT * FROM table WHERE col1 = $foo", "$", WithParameterPrefixes('$'))
+	if(err == nil) {
+		test.Log("Test DollarMarkerWithDollarOutput: Expected an error for colliding markers")
+		test.Fail()
+	}
+
+	_, err = ParseNamedParameterQuery("SELECT * FROM table WHERE col1 = ?foo", "?", WithParameterPrefixes('?'))
+	if(err == nil) {
+		test.Log("Test QuestionMarkerWithQuestionOutput: Expected an error for colliding markers")
+		test.Fail()
+	}
+}
+
+func TestSetValueStrict(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var count int
+	var err error
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo", "?")
+
+	accepted := []interface{} {
+		nil,
+		1,
+		uint8(2),
+		3.5,
+		true,
+		"text",
+		[]byte("bytes"),
+		time.Now(),
+		&count,
+		ValuerParameterTest{"secret"},
+		[]int{1, 2},
+	}
+
+	for _, value := range accepted {
+		err = query.SetValueStrict("foo", value)
+		if(err != nil) {
+			test.Log("Test StrictAccepted: unexpected error for ", value, ": ", err)
+			test.Fail()
+		}
+	}
+
+	rejected := []interface{} {
+		map[string]string{"a": "b"},
+		make(chan int),
+		func() {},
+		struct{ Foo int }{1},
+		[]map[string]string{nil},
+	}
+
+	query.SetValue("foo", "kept")
+
+	for _, value := range rejected {
+
+		err = query.SetValueStrict("foo", value)
+		if(err == nil || !strings.Contains(err.Error(), "'foo'") || !strings.Contains(err.Error(), fmt.Sprintf("%T", value))) {
+			test.Log("Test StrictRejected: Expected an error naming the parameter and type of ", value, ", Actual: ", err)
+			test.Fail()
+		}
+	}
+
+	if(query.GetParsedParameters()[0] != "kept") {
+		test.Log("Test StrictRejectedNotSet: Actual: ", query.GetParsedParameters())
+		test.Fail()
+	}
+
+	// slices which aren't expanded are bound whole, which database/sql can't do.
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :foo", "?", WithoutSliceExpansion())
+	if(query.SetValueStrict("foo", []int{1, 2}) == nil) {
+		test.Log("Test StrictUnexpandedSlice: Expected an error")
+		test.Fail()
+	}
+}
+
+func TestNilValues(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var typedNil *int
+	var parameters []interface{}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :untyped AND col2 = :typed AND col3 = :unset", "?")
+	query.SetValue("untyped", nil)
+	query.SetValue("typed", typedNil)
+
+	for _, parameter := range query.GetParsedParameters() {
+		if(parameter != nil) {
+			test.Log("Test NilValues: Expected only plain nil parameters, Actual: ", query.GetParsedParameters())
+			test.Fail()
+		}
+	}
+
+	if(!query.IsValueSet("untyped") || !query.IsValueSet("typed")) {
+		test.Log("Test NilValuesAreSet: Expected nil values to be recorded as set")
+		test.Fail()
+	}
+
+	if(query.IsValueSet("unset") || query.IsValueSet("unknown")) {
+		test.Log("Test UnsetValues: Expected unset and unknown parameters not to be set")
+		test.Fail()
+	}
+
+	query.Reset()
+
+	if(query.IsValueSet("untyped")) {
+		test.Log("Test ResetClearsSet: Expected Reset to clear set values")
+		test.Fail()
+	}
+
+	parameters, _ = query.Bind(map[string]interface{} {
+		"untyped": nil,
+		"typed": typedNil,
+		"unset": 1,
+	})
+
+	if(parameters[0] != nil || parameters[1] != nil) {
+		test.Log("Test BindTypedNil: Expected plain nil parameters, Actual: ", parameters)
+		test.Fail()
+	}
+}
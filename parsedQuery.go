@@ -0,0 +1,103 @@
+package namedParameterQuery
+
+/*
+	ParsedQuery is the immutable result of parsing a query: it has no values, and none of its methods modify it,
+	so a single ParsedQuery may be stored in a package-level variable and used by any number of goroutines at once.
+	Values are set on the Binding each use gets from NewBinding.
+	It is not recommended to create zero-valued ParsedQuery objects by yourself;
+	instead use ParseQuery
+*/
+type ParsedQuery struct {
+	// The parsed query, used as a template which is cloned for every binding and never bound itself.
+	template *NamedParameterQuery
+}
+
+/*
+	Binding holds the values of one use of a ParsedQuery. It is cheap to create, and meant to be used
+	by a single goroutine, then dropped; a Binding is not safe for concurrent use.
+*/
+type Binding struct {
+	query *NamedParameterQuery
+}
+
+/*
+	ParseQuery parses the given [queryText] once, for the given [dialect], with the given [options],
+	as NewNamedParameterQueryForDialect does. If the query is malformed, or the dialect unknown, an error is returned.
+*/
+func ParseQuery(queryText string, dialect SQLDialect, options ...Option) (*ParsedQuery, error) {
+
+	var template *NamedParameterQuery
+	var err error
+
+	template, err = NewNamedParameterQueryForDialect(queryText, dialect, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &ParsedQuery{template: template}, nil
+}
+
+/*
+	NewBinding returns a new Binding of npq query, without any value set.
+	Only the values are allocated, since the parsed query is shared.
+*/
+func (parsed *ParsedQuery) NewBinding() *Binding {
+	return &Binding{query: parsed.template.Clone()}
+}
+
+/*
+	SQL returns the revised query, with positional placeholders, as written before any slice value is expanded.
+*/
+func (parsed *ParsedQuery) SQL() string {
+	return parsed.template.revisedQuery
+}
+
+/*
+	GetParameterNames returns the distinct names of the parameters used in the query,
+	in the order they first appear in it.
+*/
+func (parsed *ParsedQuery) GetParameterNames() []string {
+	return parsed.template.GetParameterNames()
+}
+
+/*
+	SetValue sets the value of the given [parameterName], as NamedParameterQuery.SetValue does.
+*/
+func (binding *Binding) SetValue(parameterName string, parameterValue interface{}) {
+	binding.query.SetValue(parameterName, parameterValue)
+}
+
+/*
+	SetValuesFromMap sets every value of the given [parameters] map, as NamedParameterQuery.SetValuesFromMap does.
+*/
+func (binding *Binding) SetValuesFromMap(parameters map[string]interface{}) {
+	binding.query.SetValuesFromMap(parameters)
+}
+
+/*
+	SetValuesFromStruct sets every field of the given struct [parameters], as NamedParameterQuery.SetValuesFromStruct does.
+*/
+func (binding *Binding) SetValuesFromStruct(parameters interface{}) error {
+	return binding.query.SetValuesFromStruct(parameters)
+}
+
+/*
+	SQL returns the query to give the driver, with slice values expanded; see NamedParameterQuery.GetParsedQuery.
+*/
+func (binding *Binding) SQL() string {
+	return binding.query.GetParsedQuery()
+}
+
+/*
+	Args returns the arguments to give the driver along with SQL; see NamedParameterQuery.GetParsedArguments.
+*/
+func (binding *Binding) Args() []interface{} {
+	return binding.query.GetParsedArguments()
+}
+
+/*
+	Query returns the NamedParameterQuery which holds the values of npq binding,
+	for the methods Binding doesn't have. It must not be shared with other goroutines either.
+*/
+func (binding *Binding) Query() *NamedParameterQuery {
+	return binding.query
+}
@@ -0,0 +1,105 @@
+package namedParameterQuery
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestParsedQuery(test *testing.T) {
+
+	var parsed *ParsedQuery
+	var first *Binding
+	var second *Binding
+	var err error
+
+	parsed, err = ParseQuery("SELECT * FROM table WHERE col1 = :foo AND col2 IN (:ids) AND col3 = :foo", DialectPostgres)
+	if(err != nil) {
+		test.Log("Test ParsedQuery: unexpected error: ", err)
+		test.FailNow()
+	}
+
+	first = parsed.NewBinding()
+	second = parsed.NewBinding()
+
+	first.SetValue("foo", 1)
+	first.SetValue("ids", []int{2, 3})
+	second.SetValuesFromMap(map[string]interface{} {
+		"foo": "second",
+		"ids": 4,
+	})
+
+	if(first.SQL() != "SELECT * FROM table WHERE col1 = $1 AND col2 IN ($2, $3) AND col3 = $4") {
+		test.Log("Test BindingSQL: Actual: ", first.SQL())
+		test.Fail()
+	}
+	verifyParameters("BindingArgs", test, first.Args(), []interface{} {1, 2, 3, 1})
+	verifyParameters("OtherBindingArgs", test, second.Args(), []interface{} {"second", 4, "second"})
+
+	if(parsed.SQL() != "SELECT * FROM table WHERE col1 = $1 AND col2 IN ($2) AND col3 = $3" || parsed.template.IsValueSet("foo")) {
+		test.Log("Test ParsedQueryUnbound: Actual: ", parsed.template.String())
+		test.Fail()
+	}
+
+	if(fmt.Sprint(parsed.GetParameterNames()) != "[foo ids]") {
+		test.Log("Test ParsedQueryNames: Actual: ", parsed.GetParameterNames())
+		test.Fail()
+	}
+
+	_, err = ParseQuery("SELECT * FROM table WHERE col1 = 'foo", DialectPostgres)
+	if(err == nil) {
+		test.Log("Test ParsedQueryError: Expected an error for a malformed query")
+		test.Fail()
+	}
+
+	_, err = ParseQuery("SELECT * FROM table WHERE col1 = :foo", nil)
+	if(err == nil) {
+		test.Log("Test ParsedQueryDialect: Expected an error for a nil dialect")
+		test.Fail()
+	}
+}
+
+/*
+	Binds the same parsed query from many goroutines at once; run with -race.
+*/
+func TestConcurrentBindings(test *testing.T) {
+
+	var parsed *ParsedQuery
+	var group sync.WaitGroup
+	var err error
+
+	parsed, err = ParseQuery("SELECT * FROM table WHERE col1 = :foo AND col2 IN (:ids) AND col3 = :bar", DialectPostgres)
+	if(err != nil) {
+		test.Log("Test ConcurrentBindings: unexpected error: ", err)
+		test.FailNow()
+	}
+
+	for i := 0; i < 64; i++ {
+
+		group.Add(1)
+		go func(i int) {
+
+			var binding *Binding
+			var args []interface{}
+
+			defer group.Done()
+
+			for j := 0; j < 100; j++ {
+
+				binding = parsed.NewBinding()
+				binding.SetValue("foo", i)
+				binding.SetValue("ids", []int{i, j})
+				binding.SetValue("bar", j)
+
+				args = binding.Args()
+				if(binding.SQL() != "SELECT * FROM table WHERE col1 = $1 AND col2 IN ($2, $3) AND col3 = $4" ||
+					len(args) != 4 || args[0] != i || args[1] != i || args[2] != j || args[3] != j) {
+					test.Log("Test ConcurrentBindings: Actual: ", binding.SQL(), args)
+					test.Fail()
+					return
+				}
+			}
+		}(i)
+	}
+	group.Wait()
+}
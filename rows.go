@@ -0,0 +1,338 @@
+package namedParameterQuery
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+	ExpandRows turns the "VALUES (...)" tuple of an INSERT query into one tuple per element of [rows],
+	each bound from its row, for a multi-row insert:
+		type Row struct {
+			A int `sqlParameterName:"a"`
+			B int `sqlParameterName:"b"`
+		}
+		query := NewNamedParameterQuery("INSERT INTO t (a, b) VALUES (:a, :b)", "$")
+		query.ExpandRows([]Row{{A: 1, B: 2}, {A: 3, B: 4}})
+		// INSERT INTO t (a, b) VALUES ($1, $2), ($3, $4)
+	[rows] is a slice or an array of structs, or pointers to structs, whose values are set as SetValuesFromStruct does,
+	or of map[string]interface{}, whose values are set as SetValuesFromMap does.
+	Every row must give a value to every parameter of the tuple, or else an error is returned and npq query is left as it was.
+	So it is if [rows] is empty, or if the query has no VALUES tuple.
+	Parameters outside of the tuple, e.g. in an "ON CONFLICT" clause, keep their values and can still be set by name,
+	while setting a parameter of the tuple by name afterwards sets it for every row.
+	ExpandRows may be called again, with other rows, which replace the previous ones.
+*/
+func (npq *NamedParameterQuery) ExpandRows(rows interface{}) error {
+
+	var reflected reflect.Value
+	var template *NamedParameterQuery
+	var row *NamedParameterQuery
+	var rowValues [][]interface{}
+	var values []interface{}
+	var open int
+	var close int
+	var err error
+
+	reflected = reflect.ValueOf(rows)
+
+	if reflected.Kind() != reflect.Slice && reflected.Kind() != reflect.Array {
+		return fmt.Errorf("unable to expand query rows: rows are a %T, not a slice", rows)
+	}
+
+	if reflected.Len() == 0 {
+		return errors.New("unable to expand query rows: no rows given")
+	}
+
+	// rows always replace the single tuple of the original query, even when expanded already.
+	template = npq.Clone()
+	template.recordLiterals = true
+	template.setQuery(npq.originalQuery)
+
+	open, close, err = findValuesTuple(template)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < reflected.Len(); i++ {
+
+		row = template.Clone()
+		err = row.setRowValues(reflected.Index(i).Interface())
+		if err != nil {
+			return fmt.Errorf("unable to expand query rows: row %d: %w", i, err)
+		}
+
+		values = nil
+
+		for _, span := range template.placeholders {
+
+			if span.start < open || span.end > close {
+				continue
+			}
+
+			if !row.set[span.position] {
+				return fmt.Errorf("unable to expand query rows: row %d: %w for parameter '%s'", i, ErrMissingValue, span.name)
+			}
+			values = append(values, row.parameters[span.position])
+		}
+		rowValues = append(rowValues, values)
+	}
+
+	npq.setRows(template, open, close, rowValues)
+	return nil
+}
+
+/*
+	setRowValues sets the values of the given [row], a map[string]interface{} or a struct.
+*/
+func (npq *NamedParameterQuery) setRowValues(row interface{}) error {
+
+	var values map[string]interface{}
+	var isMap bool
+
+	values, isMap = row.(map[string]interface{})
+	if isMap {
+		npq.SetValuesFromMap(values)
+		return nil
+	}
+	return npq.SetValuesFromStruct(row)
+}
+
+/*
+	rowPlaceholder identifies the placeholders written with the same ordinal when the dialect reuses them:
+	those of a parameter within a row, or outside of the tuple when row is -1.
+*/
+type rowPlaceholder struct {
+	row int
+	name string
+}
+
+/*
+	setRows rewrites npq query as the freshly parsed [template], whose tuple spans from [open] to [close] in its revised query,
+	with that tuple written once per row of [rowValues], which are the values of the tuple's placeholders, in order.
+	Values of parameters outside of the tuple are taken from npq query.
+*/
+func (npq *NamedParameterQuery) setRows(template *NamedParameterQuery, open int, close int, rowValues [][]interface{}) {
+
+	var query bytes.Buffer
+	var namedParameters []namedParameter
+	var indices map[string]int
+	var parameters []interface{}
+	var set []bool
+	var positionalNames []string
+	var placeholders []placeholderSpan
+	var previousValues map[string]interface{}
+	var ordinals map[rowPlaceholder]int
+	var occurrences map[string]int
+	var tuple []placeholderSpan
+	var value interface{}
+	var present bool
+	var last int
+
+	// writes the placeholder of [span] in [row], adding a positional parameter unless the placeholder is reused.
+	write := func(span placeholderSpan, row int, value interface{}, isSet bool) {
+
+		var placeholder string
+		var ordinal int
+		var found bool
+
+		ordinal, found = ordinals[rowPlaceholder{row, span.name}]
+
+		if !found || !npq.reusesOrdinals() {
+
+			parameters = append(parameters, value)
+			set = append(set, isSet)
+			positionalNames = append(positionalNames, span.name)
+			ordinal = len(parameters)
+			ordinals[rowPlaceholder{row, span.name}] = ordinal
+
+			namedParameters[indices[span.name]].positions = append(namedParameters[indices[span.name]].positions, ordinal-1)
+		}
+
+		placeholder = npq.formatPlaceholder(span.name, occurrences[span.name], ordinal)
+
+		placeholders = append(placeholders, placeholderSpan{
+			start: query.Len(),
+			end: query.Len() + len(placeholder),
+			position: ordinal - 1,
+			name: span.name,
+			occurrence: occurrences[span.name],
+		})
+		query.WriteString(placeholder)
+		occurrences[span.name]++
+	}
+
+	// writes the placeholder of [span], outside of the tuple, with the value it had.
+	writeOutside := func(span placeholderSpan) {
+
+		value, present = previousValues[span.name]
+		if !present {
+			value = npq.defaultValue
+		}
+		write(span, -1, value, present)
+	}
+
+	// values set outside of the tuple are kept by name.
+	previousValues = make(map[string]interface{})
+
+	for _, parameter := range npq.namedParameters {
+		if len(parameter.positions) > 0 && npq.set[parameter.positions[0]] {
+			previousValues[parameter.name] = npq.parameters[parameter.positions[0]]
+		}
+	}
+
+	namedParameters = make([]namedParameter, len(template.namedParameters))
+	indices = make(map[string]int, len(template.namedParameters))
+	ordinals = make(map[rowPlaceholder]int)
+	occurrences = make(map[string]int, len(template.namedParameters))
+
+	for i, parameter := range template.namedParameters {
+		namedParameters[i] = namedParameter{name: parameter.name, offsets: parameter.offsets, spellings: parameter.spellings}
+		indices[parameter.name] = i
+	}
+
+	for _, span := range template.placeholders {
+
+		if span.start >= open && span.end <= close {
+			tuple = append(tuple, span)
+			continue
+		}
+
+		if span.start < open {
+			query.WriteString(template.revisedQuery[last:span.start])
+			writeOutside(span)
+			last = span.end
+		}
+	}
+
+	query.WriteString(template.revisedQuery[last:open])
+
+	for row, values := range rowValues {
+
+		if row > 0 {
+			query.WriteString(", ")
+		}
+
+		last = open
+
+		for i, span := range tuple {
+			query.WriteString(template.revisedQuery[last:span.start])
+			write(span, row, values[i], true)
+			last = span.end
+		}
+
+		query.WriteString(template.revisedQuery[last:close])
+	}
+
+	last = close
+
+	for _, span := range template.placeholders {
+
+		if span.start >= close {
+			query.WriteString(template.revisedQuery[last:span.start])
+			writeOutside(span)
+			last = span.end
+		}
+	}
+
+	query.WriteString(template.revisedQuery[last:])
+
+	npq.revisedQuery = query.String()
+	npq.namedParameters = namedParameters
+	npq.parameters = parameters
+	npq.set = set
+	npq.positionalNames = positionalNames
+	npq.placeholders = placeholders
+	npq.semicolons = template.semicolons
+}
+
+/*
+	textSpan locates some text written in the revised query.
+*/
+type textSpan struct {
+	// The byte offsets in revisedQuery where the text starts and ends.
+	start int
+	end int
+}
+
+/*
+	findValuesTuple returns where the parenthesized tuple following the VALUES keyword of the revised query
+	of [template] starts and ends, its closing parenthesis included. [template] must have been parsed
+	with recordLiterals set, so that the literals, quoted identifiers and comments it recorded are skipped,
+	as are its placeholders.
+*/
+func findValuesTuple(template *NamedParameterQuery) (int, int, error) {
+
+	var queryText string
+	var literal int
+	var placeholder int
+	var open int
+	var depth int
+
+	queryText = template.revisedQuery
+	open = -1
+
+	for i := 0; i < len(queryText); {
+
+		if literal < len(template.literals) && template.literals[literal].start <= i {
+			i = template.literals[literal].end
+			literal++
+			continue
+		}
+
+		if placeholder < len(template.placeholders) && template.placeholders[placeholder].start <= i {
+			i = template.placeholders[placeholder].end
+			placeholder++
+			continue
+		}
+
+		switch {
+		case open < 0 && (queryText[i] == 'V' || queryText[i] == 'v') && len(queryText)-i >= 6 && strings.EqualFold(queryText[i:i+6], "values") &&
+			!followsIdentifier(queryText, i) && !startsIdentifier(queryText[i+6:]):
+
+			// a column named "values" is not followed by a tuple.
+			i += 6
+			for i < len(queryText) && unicode.IsSpace(rune(queryText[i])) {
+				i++
+			}
+
+			if strings.HasPrefix(queryText[i:], "(") {
+				open = i
+			}
+			continue
+
+		case open >= 0 && queryText[i] == '(':
+			depth++
+
+		case open >= 0 && queryText[i] == ')':
+
+			depth--
+			if depth == 0 {
+				return open, i + 1, nil
+			}
+		}
+
+		i++
+	}
+
+	if open < 0 {
+		return 0, 0, errors.New("unable to expand query rows: the query has no VALUES tuple")
+	}
+	return 0, 0, errors.New("unable to expand query rows: the VALUES tuple is not closed")
+}
+
+/*
+	startsIdentifier returns true if [text] starts with a rune which may be part of an identifier.
+*/
+func startsIdentifier(text string) bool {
+
+	var character rune
+
+	character, _ = utf8.DecodeRuneInString(text)
+	return unicode.IsLetter(character) || unicode.IsDigit(character) || character == '_'
+}
@@ -0,0 +1,177 @@
+package namedParameterQuery
+
+import (
+	"strings"
+	"testing"
+)
+
+type RowParameterTest struct {
+	A int `sqlParameterName:"a"`
+	B string `sqlParameterName:"b"`
+}
+
+func TestExpandRows(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	query = NewNamedParameterQuery("INSERT INTO t (a, b, c) VALUES (:a, lower(:b), 'x:y') ON CONFLICT (a) DO UPDATE SET c = :c", "$")
+	query.SetValue("c", "conflict")
+
+	err = query.ExpandRows([]RowParameterTest{{1, "one"}, {2, "two"}, {3, "three"}})
+	if(err != nil) {
+		test.Log("Test ExpandRows: unexpected error: ", err)
+		test.FailNow()
+	}
+
+	if(query.GetParsedQuery() != "INSERT INTO t (a, b, c) VALUES ($1, lower($2), 'x:y'), ($3, lower($4), 'x:y'), ($5, lower($6), 'x:y') ON CONFLICT (a) DO UPDATE SET c = $7") {
+		test.Log("Test ExpandRowsQuery: Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+	verifyParameters("ExpandRowsValues", test, query.GetParsedParameters(), []interface{} {1, "one", 2, "two", 3, "three", "conflict"})
+
+	// expanding again replaces the rows, maps and pointers included.
+	err = query.ExpandRows([]interface{} {
+		map[string]interface{} {"a": 4, "b": "four"},
+		&RowParameterTest{5, "five"},
+	})
+	if(err != nil) {
+		test.Log("Test ExpandRowsAgain: unexpected error: ", err)
+		test.FailNow()
+	}
+
+	if(query.GetParsedQuery() != "INSERT INTO t (a, b, c) VALUES ($1, lower($2), 'x:y'), ($3, lower($4), 'x:y') ON CONFLICT (a) DO UPDATE SET c = $5") {
+		test.Log("Test ExpandRowsAgainQuery: Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+	verifyParameters("ExpandRowsAgainValues", test, query.GetParsedParameters(), []interface{} {4, "four", 5, "five", "conflict"})
+
+	if(strings.Join(query.GetPositionalNames(), ",") != "a,b,a,b,c" || query.Validate() != nil) {
+		test.Log("Test ExpandRowsNames: Actual: ", query.GetPositionalNames(), query.Validate())
+		test.Fail()
+	}
+
+	// a tuple parameter set by name afterwards is set for every row.
+	query.SetValue("a", 0)
+	verifyParameters("ExpandRowsSetValue", test, query.GetParsedParameters(), []interface{} {0, "four", 0, "five", "conflict"})
+}
+
+func TestExpandRowsDialects(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	rows := []map[string]interface{} {
+		{"a": 1, "b": "one"},
+		{"a": 2, "b": "two"},
+	}
+
+	query = NewNamedParameterQuery("insert into t (a, b, c) values (:a, :b, :a)", "?")
+	err = query.ExpandRows(rows)
+
+	if(err != nil || query.GetParsedQuery() != "insert into t (a, b, c) values (?, ?, ?), (?, ?, ?)") {
+		test.Log("Test ExpandRowsMySQL: Actual: ", query.GetParsedQuery(), err)
+		test.Fail()
+	}
+	verifyParameters("ExpandRowsMySQLValues", test, query.GetParsedParameters(), []interface{} {1, "one", 1, 2, "two", 2})
+
+	// reused placeholders are reused within a row only.
+	query = NewNamedParameterQuery("INSERT INTO t (a, b, c) VALUES (:a, :b, :a)", "?N")
+	err = query.ExpandRows(rows)
+
+	if(err != nil || query.GetParsedQuery() != "INSERT INTO t (a, b, c) VALUES (?1, ?2, ?1), (?3, ?4, ?3)") {
+		test.Log("Test ExpandRowsSQLite: Actual: ", query.GetParsedQuery(), err)
+		test.Fail()
+	}
+	verifyParameters("ExpandRowsSQLiteValues", test, query.GetParsedParameters(), []interface{} {1, "one", 2, "two"})
+
+	query = NewNamedParameterQuery("INSERT INTO t (\"values\", values) /* VALUES (:c) */ VALUES(:a, :b)", "$")
+	err = query.ExpandRows(rows)
+
+	if(err != nil || query.GetParsedQuery() != "INSERT INTO t (\"values\", values) /* VALUES (:c) */ VALUES($1, $2), ($3, $4)") {
+		test.Log("Test ExpandRowsValuesColumn: Actual: ", query.GetParsedQuery(), err)
+		test.Fail()
+	}
+
+	// literals are skipped as the parser skips them, escape and dollar-quoted strings included.
+	query = NewNamedParameterQuery("INSERT INTO t (a, b, c, d) VALUES (E'it\\'s (', :a, $$ ) $$, :b)", "$")
+	err = query.ExpandRows(rows)
+
+	if(err != nil || query.GetParsedQuery() != "INSERT INTO t (a, b, c, d) VALUES (E'it\\'s (', $1, $$ ) $$, $2), (E'it\\'s (', $3, $$ ) $$, $4)") {
+		test.Log("Test ExpandRowsEscapedLiterals: Actual: ", query.GetParsedQuery(), err)
+		test.Fail()
+	}
+}
+
+func TestExpandRowsPlaceholderOffset(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	query = NewNamedParameterQuery("INSERT INTO t (a, b) VALUES (:a, :b) ON CONFLICT DO UPDATE SET b = :c", "$")
+	query.SetValue("c", 7)
+
+	err = query.ExpandRows([]map[string]interface{} {{"a": 1, "b": 2}, {"a": 3, "b": 4}})
+	if(err != nil) {
+		test.Log("Test ExpandRowsOffset: unexpected error: ", err)
+		test.FailNow()
+	}
+
+	// the rows are kept, and renumbered along with the placeholders outside of the tuple.
+	query.SetPlaceholderOffset(2)
+
+	if(query.GetParsedQuery() != "INSERT INTO t (a, b) VALUES ($3, $4), ($5, $6) ON CONFLICT DO UPDATE SET b = $7") {
+		test.Log("Test ExpandRowsOffsetQuery: Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+	verifyParameters("ExpandRowsOffsetValues", test, query.GetParsedParameters(), []interface{} {1, 2, 3, 4, 7})
+
+	query.SetValue("c", 8)
+	query.SetPlaceholderOffset(0)
+
+	if(query.GetParsedQuery() != "INSERT INTO t (a, b) VALUES ($1, $2), ($3, $4) ON CONFLICT DO UPDATE SET b = $5") {
+		test.Log("Test ExpandRowsNoOffsetQuery: Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+	verifyParameters("ExpandRowsNoOffsetValues", test, query.GetParsedParameters(), []interface{} {1, 2, 3, 4, 8})
+}
+
+func TestExpandRowsErrors(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	queryText := "INSERT INTO t (a, b) VALUES (:a, :b)"
+
+	for name, rows := range map[string]interface{} {
+		"EmptyRows": []RowParameterTest{},
+		"NotASlice": RowParameterTest{},
+		"MismatchedRow": []map[string]interface{} {{"a": 1, "b": 2}, {"a": 3}},
+		"InvalidRow": []string{"a"},
+	} {
+
+		query = NewNamedParameterQuery(queryText, "$")
+		err = query.ExpandRows(rows)
+
+		if(err == nil || query.GetParsedQuery() != "INSERT INTO t (a, b) VALUES ($1, $2)") {
+			test.Log("Test ", name, ": Expected an error and an untouched query, Actual: ", query.GetParsedQuery(), err)
+			test.Fail()
+		}
+	}
+
+	for _, queryText := range []string {
+		"INSERT INTO t (a) SELECT :a",
+		"INSERT INTO t (values) SELECT :a -- VALUES (:b)",
+		"INSERT INTO t (a) VALUES :a",
+		"INSERT INTO t (a) VALUES (:a",
+	} {
+
+		query = NewNamedParameterQuery(queryText, "$")
+		err = query.ExpandRows([]RowParameterTest{{1, "one"}})
+
+		if(err == nil) {
+			test.Log("Test ExpandRowsWithoutTuple: Expected an error for ", queryText)
+			test.Fail()
+		}
+	}
+}
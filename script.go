@@ -0,0 +1,137 @@
+package namedParameterQuery
+
+import (
+	"strings"
+)
+
+/*
+	NamedParameterScript handles scripts made of several SQL statements separated by semicolons,
+	such as migrations. Each statement is parsed on its own, with its own positional parameters,
+	since drivers do not accept positional parameters spanning statements.
+	Values are set for the whole script, and apply to every statement using the parameter.
+	It is not recommended to create zero-valued NamedParameterScript objects by yourself;
+	instead use NewNamedParameterScript
+*/
+type NamedParameterScript struct {
+	// The parsed statements, in order.
+	statements []*NamedParameterQuery
+}
+
+/*
+	NewNamedParameterScript creates a new named parameter script from the given [scriptText].
+	The script is split on every semicolon which is not inside a quoted string, an identifier or a comment,
+	and each statement is then parsed as NewNamedParameterQuery would, with the same [argIndication] and [options].
+	Statements which are empty, or made of whitespace only, are dropped.
+*/
+func NewNamedParameterScript(scriptText string, argIndication string, options ...Option) *NamedParameterScript {
+
+	var ret *NamedParameterScript
+
+	ret, _ = parseNamedParameterScript(scriptText, argIndication, options)
+	return ret
+}
+
+/*
+	ParseNamedParameterScript works like NewNamedParameterScript, but returns an error
+	instead of a best-effort script when one of the statements is malformed.
+	See ParseNamedParameterQuery. Error offsets are relative to the script text.
+*/
+func ParseNamedParameterScript(scriptText string, argIndication string, options ...Option) (*NamedParameterScript, error) {
+
+	var ret *NamedParameterScript
+	var err error
+
+	_, err = parseArgIndication(argIndication)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err = parseNamedParameterScript(scriptText, argIndication, options)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+/*
+	parseNamedParameterScript splits and parses the given [scriptText], returning the first error found along with the script.
+*/
+func parseNamedParameterScript(scriptText string, argIndication string, options []Option) (*NamedParameterScript, error) {
+
+	var ret *NamedParameterScript
+	var whole *NamedParameterQuery
+	var statement *NamedParameterQuery
+	var statementText string
+	var start int
+	var err error
+
+	// the whole script is parsed once to find out which semicolons end a statement.
+	whole = newNamedParameterQuery(dialectOf(argIndication), options)
+	err = whole.checkPrefixes()
+	if err == nil {
+		err = whole.setQuery(scriptText)
+	}
+
+	ret = new(NamedParameterScript)
+
+	for _, end := range append(whole.semicolons, len(scriptText)) {
+
+		statementText = scriptText[start:end]
+		start = end + 1
+
+		if len(strings.TrimSpace(statementText)) <= 0 {
+			continue
+		}
+
+		statement = newNamedParameterQuery(dialectOf(argIndication), options)
+		statement.setQuery(strings.TrimSpace(statementText))
+		ret.statements = append(ret.statements, statement)
+	}
+	return ret, err
+}
+
+/*
+	Statements returns the parsed statements of the script, in order.
+	Each of them gives its own GetParsedQuery and GetParsedParameters, to be executed one after the other.
+*/
+func (nps *NamedParameterScript) Statements() []*NamedParameterQuery {
+	return nps.statements
+}
+
+/*
+	SetValue sets the value of the given [parameterName] to the given [parameterValue]
+	in every statement which uses it.
+*/
+func (nps *NamedParameterScript) SetValue(parameterName string, parameterValue interface{}) {
+
+	for _, statement := range nps.statements {
+		statement.SetValue(parameterName, parameterValue)
+	}
+}
+
+/*
+	SetValuesFromMap works like NamedParameterQuery.SetValuesFromMap, for every statement of the script.
+*/
+func (nps *NamedParameterScript) SetValuesFromMap(parameters map[string]interface{}) {
+
+	for _, statement := range nps.statements {
+		statement.SetValuesFromMap(parameters)
+	}
+}
+
+/*
+	SetValuesFromStruct works like NamedParameterQuery.SetValuesFromStruct, for every statement of the script.
+*/
+func (nps *NamedParameterScript) SetValuesFromStruct(parameters interface{}) error {
+
+	var err error
+
+	for _, statement := range nps.statements {
+
+		err = statement.SetValuesFromStruct(parameters)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
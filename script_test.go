@@ -0,0 +1,61 @@
+package namedParameterQuery
+
+import (
+	"testing"
+)
+
+func TestNamedParameterScript(test *testing.T) {
+
+	var script *NamedParameterScript
+	var statements []*NamedParameterQuery
+	var err error
+
+	scriptText := "INSERT INTO t (a) VALUES (:foo);\n" +
+		"UPDATE t SET b = ';:not' -- ; :comment\n" +
+		"WHERE a = :foo AND c = :bar;\n" +
+		"DO $$ BEGIN PERFORM 1; END $$;\n" +
+		"/* ; */ DELETE FROM t WHERE c = :bar;\n"
+
+	script, err = ParseNamedParameterScript(scriptText, "$")
+	if(err != nil) {
+		test.Log("Test Script: unexpected error: ", err)
+		test.FailNow()
+	}
+
+	statements = script.Statements()
+
+	expected := []string {
+		"INSERT INTO t (a) VALUES ($1)",
+		"UPDATE t SET b = ';:not' -- ; :comment\nWHERE a = $1 AND c = $2",
+		"DO $$ BEGIN PERFORM 1; END $$",
+		"/* ; */ DELETE FROM t WHERE c = $1",
+	}
+
+	if(len(statements) != len(expected)) {
+		test.Log("Test Script: Expected ", len(expected), " statements, Actual: ", len(statements))
+		test.FailNow()
+	}
+
+	for index, statement := range statements {
+		if(statement.GetParsedQuery() != expected[index]) {
+			test.Log("Test Script: Expected statement ", index, " to be '", expected[index], "', Actual: '", statement.GetParsedQuery(), "'")
+			test.Fail()
+		}
+	}
+
+	script.SetValue("foo", 1)
+	script.SetValuesFromMap(map[string]interface{} {
+		"bar": "two",
+	})
+
+	verifyStructParameters("ScriptStatement0", test, statements[0], []interface{} {1})
+	verifyStructParameters("ScriptStatement1", test, statements[1], []interface{} {1, "two"})
+	verifyStructParameters("ScriptStatement2", test, statements[2], []interface{} {})
+	verifyStructParameters("ScriptStatement3", test, statements[3], []interface{} {"two"})
+
+	_, err = ParseNamedParameterScript("SELECT 1; SELECT 'unterminated", "$")
+	if(err == nil) {
+		test.Log("Test ScriptError: Expected an error for an unterminated literal")
+		test.Fail()
+	}
+}
@@ -0,0 +1,557 @@
+package namedParameterQuery
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+var timeType = reflect.TypeOf(time.Time{})
+
+/*
+	SetValuesFromStruct uses reflection to find every public field of the given struct [parameters]
+	and set their key/value as named parameters in npq query.
+	The given [parameters] may be a struct, or any chain of pointers and interfaces leading to one, such as a **struct;
+	otherwise, or if one of them is nil, npq will return an error.
+	If you do not wish for a field in the struct to be added by its literal name,
+	The struct may optionally specify the sqlParameterName as a tag on the field.
+	e.g., a struct field may say something like:
+		type Test struct {
+			Foo string `sqlParameterName:"foobar"`
+		}
+	Fields of embedded structs are set as if they were fields of [parameters] itself,
+	even when the type of the embedded struct is not exported, as encoding/json does.
+	Fields of nested structs, or of the structs nested fields point to, are set too, prefixed by
+	the sqlParameterPrefix tag of the nested struct field, or else its sqlParameterName tag, or else its lowercased name, and a dot:
+		type Test struct {
+			Address Address `sqlParameterName:"addr"`
+			Billing *Address
+		}
+	sets ":addr.city" from Address.City and ":billing.city" from Billing.City, in addition to ":addr" from Address itself.
+	Untagged fields of nested structs are named by the name mapper, if any, or else lowercased, as their prefix is,
+	while untagged fields of [parameters] itself keep their Go name.
+	A struct pointing back to a struct being walked, directly or not, isn't walked again.
+	Unexported fields are skipped.
+	A field tagged `sqlParameterName:"-"` is skipped too, and one tagged with the "omitempty" option,
+	e.g. `sqlParameterName:"foobar,omitempty"`, is skipped when it is empty as encoding/json tells it,
+	leaving its parameter unset rather than bound to e.g. "" or 0. A tag such as `sqlParameterName:",omitempty"`
+	keeps the field name.
+	A map with string keys tagged with the "inline" option, e.g. `sqlParameterName:",inline"`, has its entries set
+	as SetValuesFromMap would, e.g. for dynamic filters beside fixed fields; the fields beside it take precedence
+	over entries of the same name. A name in the tag, e.g. `sqlParameterName:"labels,inline"`, or a sqlParameterPrefix tag,
+	prefixes the entries as it would the fields of a nested struct, so that {"team": "core"} sets ":labels.team".
+	Maps without that option are set as any other field.
+	With WithStructTag, another tag such as `db:"foobar"` is read first, and sqlParameterName only when it's absent.
+	Two fields which resolve to the same parameter name are an error, and no value is set then;
+	except that, as in Go, a field shadows the fields of embedded structs which have the same name.
+	Structs which are values of their own, i.e. time.Time and driver.Valuer implementations such as sql.NullString,
+	are never walked nor flattened: they are set as they are, for database/sql to convert them.
+	So are []byte fields, which are never expanded as other slices are.
+	Pointer and interface fields are set to the value they point to, e.g. a *string field to a string, or nil if they are nil.
+*/
+func (npq *NamedParameterQuery) SetValuesFromStruct(parameters interface{}) error {
+	return npq.setValuesFromStruct(parameters, false)
+}
+
+/*
+	SetValuesFromStructStrict works like SetValuesFromStruct, but only sets the fields which have a sqlParameterName tag:
+	untagged fields are ignored rather than set by their field name, so that a struct with many fields
+	can't bind any of them by accident. Embedded structs are still flattened, and their tagged fields set.
+*/
+func (npq *NamedParameterQuery) SetValuesFromStructStrict(parameters interface{}) error {
+	return npq.setValuesFromStruct(parameters, true)
+}
+
+/*
+	setValuesFromStruct sets the fields of the struct, or pointer to a struct, [parameters] as named parameters.
+	If [taggedOnly] is true, fields without a sqlParameterName tag are ignored.
+*/
+func (npq *NamedParameterQuery) setValuesFromStruct(parameters interface{}, taggedOnly bool) error {
+
+	var fieldValues reflect.Value
+
+	fieldValues = reflect.ValueOf(parameters)
+
+	for fieldValues.Kind() == reflect.Ptr || fieldValues.Kind() == reflect.Interface {
+
+		if fieldValues.IsNil() {
+			return fmt.Errorf("unable to add query values from parameter: %w, got a nil %s", ErrNotAStruct, fieldValues.Kind())
+		}
+		fieldValues = fieldValues.Elem()
+	}
+
+	if fieldValues.Kind() != reflect.Struct {
+		return fmt.Errorf("unable to add query values from parameter: %w, got %s", ErrNotAStruct, fieldValues.Kind())
+	}
+
+	return npq.setStructFields(collectStructFields(fieldValues, npq.structTag, npq.fieldNameMapper, taggedOnly))
+}
+
+/*
+	structField is a struct field to set as a named parameter.
+*/
+type structField struct {
+	// The parameter name of the field.
+	name string
+
+	// The Go path of the field, e.g. "Address.City", for error messages.
+	path string
+
+	// How many embedded structs the field is found through; shallower fields shadow deeper ones, as in Go.
+	depth int
+
+	value interface{}
+}
+
+/*
+	setStructFields sets the given [fields] as named parameters.
+	A field shadows the fields of the same name found deeper in embedded structs, but two fields
+	of the same name at the same depth are an error, in which case no field is set.
+*/
+func (npq *NamedParameterQuery) setStructFields(fields []structField) error {
+
+	var shallowest map[string]int
+	var chosen map[string]int
+	var name string
+	var depth int
+	var present bool
+
+	shallowest = make(map[string]int, len(fields))
+	chosen = make(map[string]int, len(fields))
+
+	for _, field := range fields {
+
+		name = npq.normalizeName(field.name)
+		depth, present = shallowest[name]
+
+		if !present || field.depth < depth {
+			shallowest[name] = field.depth
+		}
+	}
+
+	for i, field := range fields {
+
+		name = npq.normalizeName(field.name)
+
+		if field.depth != shallowest[name] {
+			continue
+		}
+
+		if _, present = chosen[name]; present {
+			return fmt.Errorf("unable to add query values from parameter: fields %s and %s are both named '%s'", fields[chosen[name]].path, field.path, field.name)
+		}
+		chosen[name] = i
+	}
+
+	for _, i := range chosen {
+		npq.SetValue(fields[i].name, fields[i].value)
+	}
+	return nil
+}
+
+/*
+	structWalker collects the fields of a struct, and of the structs it embeds or nests, to set as named parameters.
+*/
+type structWalker struct {
+	// The tag read before sqlParameterName, if any; see WithStructTag.
+	tagKey string
+
+	// When set, names untagged fields instead of their field names; see WithFieldNameMapper.
+	nameMapper func(string) string
+
+	// Whether untagged fields are ignored.
+	taggedOnly bool
+
+	// The addresses of the structs being walked through pointers, so that a pointer cycle is walked once.
+	visiting map[uintptr]bool
+
+	// The fields collected so far.
+	fields []structField
+}
+
+/*
+	collectStructFields returns the fields of the struct [fieldValues] to set as named parameters.
+	Fields are named by their [tagKey] tag first, if [tagKey] isn't empty, and untagged ones by [nameMapper], if not nil.
+	If [taggedOnly] is true, untagged fields are ignored.
+*/
+func collectStructFields(fieldValues reflect.Value, tagKey string, nameMapper func(string) string, taggedOnly bool) []structField {
+
+	var walker structWalker
+
+	walker.tagKey = tagKey
+	walker.nameMapper = nameMapper
+	walker.taggedOnly = taggedOnly
+	walker.visiting = make(map[uintptr]bool)
+
+	// a struct given by pointer may be pointed to by its own fields too.
+	if fieldValues.CanAddr() {
+		walker.visiting[fieldValues.UnsafeAddr()] = true
+	}
+
+	walker.walk(fieldValues, "", "", 0)
+	return walker.fields
+}
+
+/*
+	walk collects the fields of the struct [fieldValues].
+	Each parameter name is prefixed by [namePrefix], and each Go path by [pathPrefix].
+	[depth] is the number of embedded structs [fieldValues] is found through.
+*/
+func (walker *structWalker) walk(fieldValues reflect.Value, namePrefix string, pathPrefix string, depth int) {
+
+	var fieldValue reflect.Value
+	var nestedValue reflect.Value
+	var parameterType reflect.Type
+	var parameterField reflect.StructField
+	var queryTag string
+	var tagOptions string
+	var prefixTag string
+	var tagged bool
+	var visibilityCharacter rune
+
+	parameterType = fieldValues.Type()
+
+	for i := 0; i < fieldValues.NumField(); i++ {
+
+		fieldValue = fieldValues.Field(i)
+		parameterField = parameterType.Field(i)
+
+		// public field?
+		visibilityCharacter, _ = utf8.DecodeRuneInString(parameterField.Name[0:])
+
+		if (!fieldValue.CanSet() && !unicode.IsUpper(visibilityCharacter)) || !fieldValue.CanInterface() {
+
+			// an embedded struct of an unexported type can't be read itself, but its exported fields can,
+			// and are promoted as encoding/json promotes them.
+			if parameterField.Anonymous {
+
+				nestedValue = fieldValue
+				if nestedValue.Kind() == reflect.Ptr && !nestedValue.IsNil() {
+					nestedValue = nestedValue.Elem()
+				}
+
+				if nestedValue.Kind() == reflect.Struct && !isValueType(nestedValue.Type()) {
+					walker.walkNested(fieldValue, nestedValue, namePrefix, pathPrefix+parameterField.Name+".", depth+1)
+				}
+			}
+			continue
+		}
+
+		// check to see if npq has a tag indicating a different query name
+		queryTag = walker.parameterTag(parameterField)
+		tagged = len(queryTag) > 0
+
+		// "-" means the field is never a parameter, as with encoding/json.
+		if queryTag == "-" {
+			continue
+		}
+		queryTag, tagOptions = splitTag(queryTag)
+
+		nestedValue = fieldValue
+		if nestedValue.Kind() == reflect.Ptr && !nestedValue.IsNil() && !isValueType(nestedValue.Type()) {
+			nestedValue = nestedValue.Elem()
+		}
+
+		// embedded structs are flattened, unless they are tagged like a regular field.
+		if parameterField.Anonymous && len(queryTag) <= 0 && !isValueType(fieldValue.Type()) {
+
+			if nestedValue.Kind() == reflect.Struct {
+				walker.walkNested(fieldValue, nestedValue, namePrefix, pathPrefix+parameterField.Name+".", depth+1)
+				continue
+			}
+		}
+
+		// nested structs are walked too, their fields prefixed by the sqlParameterPrefix tag,
+		// or else the sqlParameterName tag, or else the lowercased field name, and a dot.
+		if nestedValue.Kind() == reflect.Struct && !parameterField.Anonymous && !isValueType(nestedValue.Type()) {
+
+			prefixTag = parameterField.Tag.Get("sqlParameterPrefix")
+			if len(prefixTag) <= 0 {
+				prefixTag = queryTag
+			}
+			if len(prefixTag) <= 0 && walker.nameMapper != nil {
+				prefixTag = walker.nameMapper(parameterField.Name)
+			}
+			if len(prefixTag) <= 0 {
+				prefixTag = strings.ToLower(parameterField.Name)
+			}
+
+			walker.walkNested(fieldValue, nestedValue, namePrefix+prefixTag+".", pathPrefix+parameterField.Name+".", depth)
+		}
+
+		// a map tagged ",inline" has its entries set as parameters of their own, as SetValuesFromMap does,
+		// shadowed by the fields beside it. As for nested structs, a sqlParameterPrefix tag or a name in the tag prefixes them.
+		// Untagged maps are regular fields.
+		if hasTagOption(tagOptions, "inline") && nestedValue.Kind() == reflect.Map && nestedValue.Type().Key().Kind() == reflect.String {
+
+			prefixTag = parameterField.Tag.Get("sqlParameterPrefix")
+			if len(prefixTag) <= 0 {
+				prefixTag = queryTag
+			}
+			if len(prefixTag) > 0 {
+				prefixTag += "."
+			}
+
+			walker.walkMap(nestedValue, namePrefix+prefixTag, pathPrefix+parameterField.Name, depth+1)
+			continue
+		}
+
+		// otherwise just add the struct's name, unless only tagged fields are wanted.
+		if walker.taggedOnly && !tagged {
+			continue
+		}
+
+		// untagged fields of nested structs are named as their prefix is: mapped, or else lowercased.
+		if len(queryTag) <= 0 && walker.nameMapper != nil {
+			queryTag = walker.nameMapper(parameterField.Name)
+		}
+		if len(queryTag) <= 0 && len(namePrefix) > 0 {
+			queryTag = strings.ToLower(parameterField.Name)
+		}
+		if len(queryTag) <= 0 {
+			queryTag = parameterField.Name
+		}
+
+		// "omitempty" leaves the parameter unset rather than bind an empty value.
+		if hasTagOption(tagOptions, "omitempty") && isEmptyValue(fieldValue) {
+			continue
+		}
+
+		walker.fields = append(walker.fields, structField{
+			name: namePrefix + queryTag,
+			path: pathPrefix + parameterField.Name,
+			depth: depth,
+			value: fieldInterface(fieldValue),
+		})
+	}
+}
+
+/*
+	parameterTag returns the tag of [parameterField] which names its parameter: its tagKey tag, if the walker has one
+	and the field has it, or else its sqlParameterName tag. It is empty if the field has neither.
+*/
+func (walker *structWalker) parameterTag(parameterField reflect.StructField) string {
+
+	var tag string
+
+	if len(walker.tagKey) > 0 {
+
+		tag = parameterField.Tag.Get(walker.tagKey)
+		if len(tag) > 0 {
+			return tag
+		}
+	}
+	return parameterField.Tag.Get("sqlParameterName")
+}
+
+/*
+	splitTag splits the given [tag] into the parameter name and the comma-separated options following it,
+	as encoding/json does, e.g. "name,omitempty" into "name" and "omitempty".
+*/
+func splitTag(tag string) (string, string) {
+
+	var comma int
+
+	comma = strings.IndexByte(tag, ',')
+	if comma < 0 {
+		return tag, ""
+	}
+	return tag[:comma], tag[comma+1:]
+}
+
+/*
+	hasTagOption returns true if the comma-separated [options] of a tag include [option].
+*/
+func hasTagOption(options string, option string) bool {
+
+	for _, candidate := range strings.Split(options, ",") {
+		if candidate == option {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+	isEmptyValue returns true if [value] is empty by the rules of encoding/json's "omitempty":
+	false, 0, a nil pointer or interface, and an empty array, slice, map or string. Structs are never empty.
+*/
+func isEmptyValue(value reflect.Value) bool {
+
+	switch value.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return value.Len() == 0
+	case reflect.Bool:
+		return !value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return value.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return value.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return value.IsNil()
+	}
+	return false
+}
+
+/*
+	walkNested walks the struct [nestedValue] of the field [fieldValue], which may be a pointer to it.
+	A struct already being walked through that same pointer is skipped, which stops pointer cycles.
+*/
+func (walker *structWalker) walkNested(fieldValue reflect.Value, nestedValue reflect.Value, namePrefix string, pathPrefix string, depth int) {
+
+	var address uintptr
+
+	if fieldValue.Kind() != reflect.Ptr {
+		walker.walk(nestedValue, namePrefix, pathPrefix, depth)
+		return
+	}
+
+	address = fieldValue.Pointer()
+	if walker.visiting[address] {
+		return
+	}
+
+	walker.visiting[address] = true
+	walker.walk(nestedValue, namePrefix, pathPrefix, depth)
+	delete(walker.visiting, address)
+}
+
+/*
+	walkMap collects the entries of the map [mapValue], whose keys are strings, found at the Go path [path].
+	Each parameter name is the key prefixed by [namePrefix], and the entries of nested maps are collected too,
+	under dotted names, so that {"user": {"id": 7}} fills ":user.id". A map already being walked is skipped.
+*/
+func (walker *structWalker) walkMap(mapValue reflect.Value, namePrefix string, path string, depth int) {
+
+	var entries *reflect.MapIter
+	var entryValue reflect.Value
+	var key string
+
+	if mapValue.IsNil() || walker.visiting[mapValue.Pointer()] {
+		return
+	}
+
+	walker.visiting[mapValue.Pointer()] = true
+	entries = mapValue.MapRange()
+
+	for entries.Next() {
+
+		key = entries.Key().String()
+		entryValue = entries.Value()
+
+		walker.fields = append(walker.fields, structField{
+			name: namePrefix + key,
+			path: fmt.Sprintf("%s[%q]", path, key),
+			depth: depth,
+			value: fieldInterface(entryValue),
+		})
+
+		for entryValue.Kind() == reflect.Interface && !entryValue.IsNil() {
+			entryValue = entryValue.Elem()
+		}
+
+		if entryValue.Kind() == reflect.Map && entryValue.Type().Key().Kind() == reflect.String {
+			walker.walkMap(entryValue, namePrefix+key+".", fmt.Sprintf("%s[%q]", path, key), depth)
+		}
+	}
+	delete(walker.visiting, mapValue.Pointer())
+}
+
+/*
+	hasPointerValuer returns true if the pointer type [pointerType] implements driver.Valuer through a pointer receiver,
+	i.e. while the type it points to doesn't.
+*/
+func hasPointerValuer(pointerType reflect.Type) bool {
+	return pointerType.Kind() == reflect.Ptr && pointerType.Implements(valuerType) && !pointerType.Elem().Implements(valuerType)
+}
+
+/*
+	fieldInterface returns the value of the struct field [fieldValue] to bind.
+	Pointers and interfaces are followed down to the value they hold, any number of times, so that e.g. a *string
+	is bound as a string; a nil one is bound as nil, i.e. NULL. A driver.Valuer is bound as it is, for database/sql to call it.
+	A field whose type implements driver.Valuer through a pointer only, such as a struct with a pointer receiver Value method,
+	is bound as a pointer to a copy of it, since database/sql would otherwise get a struct it can't convert.
+*/
+func fieldInterface(fieldValue reflect.Value) interface{} {
+
+	var pointer reflect.Value
+
+	for fieldValue.Kind() == reflect.Ptr || fieldValue.Kind() == reflect.Interface {
+
+		// a nil pointer with a pointer receiver Value method is bound as it is, for database/sql to call it.
+		if fieldValue.Kind() == reflect.Ptr && hasPointerValuer(fieldValue.Type()) {
+			return fieldValue.Interface()
+		}
+
+		if fieldValue.IsNil() {
+			return nil
+		}
+
+		if fieldValue.Kind() == reflect.Ptr && fieldValue.Type().Implements(valuerType) {
+			return fieldValue.Interface()
+		}
+		fieldValue = fieldValue.Elem()
+	}
+
+	if fieldValue.Type().Implements(valuerType) || !reflect.PtrTo(fieldValue.Type()).Implements(valuerType) {
+		return fieldValue.Interface()
+	}
+
+	pointer = reflect.New(fieldValue.Type())
+	pointer.Elem().Set(fieldValue)
+	return pointer.Interface()
+}
+
+/*
+	SnakeCase returns the given Go field [name] in snake_case, e.g. "created_at" for "CreatedAt".
+	An acronym is a single word: "UserID" is "user_id", and "HTTPStatus" is "http_status".
+	It is the field name mapper of WithSnakeCaseFields.
+*/
+func SnakeCase(name string) string {
+
+	var ret strings.Builder
+	var runes []rune
+	var previous rune
+	var next rune
+
+	runes = []rune(name)
+
+	for i, character := range runes {
+
+		if i > 0 && unicode.IsUpper(character) {
+
+			previous = runes[i-1]
+			next = 0
+			if i+1 < len(runes) {
+				next = runes[i+1]
+			}
+
+			// a word starts after a lower case letter or a digit, or at the last capital of an acronym followed by a word.
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && unicode.IsLower(next)) {
+				ret.WriteByte('_')
+			}
+		}
+		ret.WriteRune(unicode.ToLower(character))
+	}
+	return ret.String()
+}
+
+/*
+	isValueType returns true if values of the given [valueType] are bound as they are, even though they may be structs:
+	time.Time, and types which implement driver.Valuer, themselves or through a pointer.
+*/
+func isValueType(valueType reflect.Type) bool {
+
+	if valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+
+	return valueType == timeType || valueType.Implements(valuerType) || reflect.PtrTo(valueType).Implements(valuerType)
+}
@@ -0,0 +1,1010 @@
+package namedParameterQuery
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+)
+
+type EmbeddedParameterTest struct {
+	Limit int `sqlParameterName:"limit"`
+	Offset int
+}
+
+type AuditParameterTest struct {
+	CreatedBy string `sqlParameterName:"createdBy"`
+}
+
+type AddressParameterTest struct {
+	City string `sqlParameterName:"city"`
+	Zip string
+}
+
+type NestedParameterTest struct {
+	EmbeddedParameterTest
+	*AuditParameterTest
+	Name string `sqlParameterName:"name"`
+	Address AddressParameterTest `sqlParameterName:"addr"`
+	Untagged AddressParameterTest
+}
+
+func TestEmbeddedStructParameters(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var parameters NestedParameterTest
+
+	parameters.Limit = 10
+	parameters.Offset = 20
+	parameters.AuditParameterTest = &AuditParameterTest{CreatedBy: "alice"}
+	parameters.Name = "foo"
+	parameters.Address = AddressParameterTest{City: "Paris", Zip: "75000"}
+	parameters.Untagged = AddressParameterTest{City: "Lyon"}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE name = :name AND creator = :createdBy AND city = :addr.city AND zip = :addr.zip LIMIT :limit OFFSET :Offset", "?")
+	query.SetValuesFromStruct(parameters)
+
+	verifyStructParameters("EmbeddedAndNestedStructs", test, query, []interface{} {
+		"foo",
+		"alice",
+		"Paris",
+		"75000",
+		10,
+		20,
+	})
+
+	// untagged nested structs are walked with their lowercased name as prefix.
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE city = :untagged.city AND other = :Untagged.city AND address = :addr", "?")
+	query.SetValuesFromStruct(parameters)
+
+	verifyStructParameters("UntaggedNestedStruct", test, query, []interface{} {
+		"Lyon",
+		nil,
+		parameters.Address,
+	})
+
+	// a nil embedded pointer is simply skipped.
+	parameters.AuditParameterTest = nil
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE creator = :createdBy AND name = :name", "?")
+	query.SetValuesFromStruct(parameters)
+
+	verifyStructParameters("NilEmbeddedPointer", test, query, []interface{} {
+		nil,
+		"foo",
+	})
+}
+
+type unexportedBaseParameterTest struct {
+	Limit int
+	offset int
+}
+
+type unexportedAuditParameterTest struct {
+	CreatedBy string `sqlParameterName:"createdBy"`
+}
+
+type UnexportedEmbeddedParameterTest struct {
+	unexportedBaseParameterTest
+	*unexportedAuditParameterTest
+	Name string
+}
+
+func TestUnexportedEmbeddedStructParameters(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	parameters := UnexportedEmbeddedParameterTest {
+		unexportedBaseParameterTest: unexportedBaseParameterTest{Limit: 10, offset: 20},
+		unexportedAuditParameterTest: &unexportedAuditParameterTest{CreatedBy: "alice"},
+		Name: "foo",
+	}
+
+	// exported fields of unexported embedded structs are promoted, their unexported fields are not.
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE name = :Name AND creator = :createdBy LIMIT :Limit OFFSET :offset", "?")
+	err = query.SetValuesFromStruct(parameters)
+
+	if(err != nil) {
+		test.Log("Test UnexportedEmbeddedStruct: unexpected error: ", err)
+		test.Fail()
+	}
+
+	verifyStructParameters("UnexportedEmbeddedStruct", test, query, []interface{} {
+		"foo",
+		"alice",
+		10,
+		nil,
+	})
+
+	if(query.IsValueSet("offset")) {
+		test.Log("Test UnexportedEmbeddedStructField: Expected unexported fields not to be set")
+		test.Fail()
+	}
+
+	parameters.unexportedAuditParameterTest = nil
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE creator = :createdBy LIMIT :Limit", "?")
+	query.SetValuesFromStruct(&parameters)
+
+	verifyStructParameters("NilUnexportedEmbeddedPointer", test, query, []interface{} {
+		nil,
+		10,
+	})
+}
+
+type PrefixedParameterTest struct {
+	Home AddressParameterTest `sqlParameterPrefix:"home" sqlParameterName:"house"`
+	Billing *AddressParameterTest
+	Created time.Time `sqlParameterName:"created"`
+	Next *PrefixedParameterTest `sqlParameterPrefix:"next"`
+}
+
+func TestNestedStructPrefixes(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var parameters PrefixedParameterTest
+	var err error
+
+	parameters.Home = AddressParameterTest{City: "Paris"}
+	parameters.Billing = &AddressParameterTest{City: "Lyon", Zip: "69000"}
+	parameters.Created = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// a cycle of pointers is walked once.
+	parameters.Next = &PrefixedParameterTest{Home: AddressParameterTest{City: "Nantes"}, Next: &parameters}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE a = :home.city AND b = :billing.city AND c = :billing.zip AND d = :created AND e = :next.home.city AND f = :next.next.home.city AND g = :house.city", "?")
+	err = query.SetValuesFromStruct(&parameters)
+
+	if(err != nil) {
+		test.Log("Test NestedStructPrefixes: unexpected error: ", err)
+		test.Fail()
+	}
+
+	verifyStructParameters("NestedStructPrefixes", test, query, []interface{} {
+		"Paris",
+		"Lyon",
+		"69000",
+		parameters.Created,
+		"Nantes",
+		nil,
+		nil,
+	})
+
+	// time.Time is a value, not a struct to walk.
+	for _, field := range collectStructFields(reflect.ValueOf(&parameters).Elem(), "", nil, false) {
+		if(strings.Contains(field.name, "created.") || strings.HasPrefix(field.name, "next.next.")) {
+			test.Log("Test NestedStructLeaves: Unexpected field ", field.name)
+			test.Fail()
+		}
+	}
+
+	// a nil nested pointer is bound as nil, and not walked.
+	parameters.Billing = nil
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE a = :Billing AND b = :billing.city", "?")
+	query.SetValuesFromStruct(parameters)
+
+	verifyStructParameters("NilNestedPointer", test, query, []interface{} {
+		nil,
+		nil,
+	})
+}
+
+type UntaggedAddressParameterTest struct {
+	City string
+}
+
+type UntaggedNestedParameterTest struct {
+	Address UntaggedAddressParameterTest
+	Name string
+}
+
+func TestUntaggedNestedLeaves(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var parameters UntaggedNestedParameterTest
+
+	parameters.Address.City = "Paris"
+	parameters.Name = "foo"
+
+	// untagged leaves of nested structs are lowercased as their prefix is, top-level ones keep their name.
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE a = :address.city AND b = :address.City AND c = :Name", "?")
+	query.SetValuesFromStruct(parameters)
+
+	verifyStructParameters("UntaggedNestedLeaves", test, query, []interface{} {
+		"Paris",
+		nil,
+		"foo",
+	})
+
+	// a name mapper names them instead.
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE a = :ADDRESS.CITY AND b = :NAME", "?", WithFieldNameMapper(strings.ToUpper))
+	query.SetValuesFromStruct(parameters)
+
+	verifyStructParameters("MappedNestedLeaves", test, query, []interface{} {
+		"Paris",
+		"foo",
+	})
+}
+
+type DbTagParameterTest struct {
+	UserId int `db:"user_id"`
+	Name string `db:"name,omitempty" sqlParameterName:"ignored"`
+	Email string `sqlParameterName:"email"`
+	Age int
+	Secret string `db:"-"`
+	Empty string `db:"" sqlParameterName:"empty"`
+}
+
+func TestStructTagKey(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var parameters DbTagParameterTest
+
+	parameters = DbTagParameterTest{UserId: 1, Name: "alice", Email: "a@b.c", Age: 30, Secret: "s", Empty: "e"}
+	queryText := "SELECT * FROM table WHERE a = :user_id AND b = :name AND c = :email AND d = :Age AND e = :Secret AND f = :empty AND g = :ignored"
+
+	query = NewNamedParameterQuery(queryText, "?", WithStructTag("db"))
+	query.SetValuesFromStruct(parameters)
+
+	verifyStructParameters("DbTags", test, query, []interface{} {
+		1,
+		"alice",
+		"a@b.c",
+		30,
+		nil,
+		"e",
+		nil,
+	})
+
+	// only tags count as tags for the strict variant, whichever they are.
+	query = NewNamedParameterQuery(queryText, "?", WithStructTag("db"))
+	query.SetValuesFromStructStrict(parameters)
+
+	verifyStructParameters("DbTagsStrict", test, query, []interface{} {
+		1,
+		"alice",
+		"a@b.c",
+		nil,
+		nil,
+		"e",
+		nil,
+	})
+
+	// without the option, db tags are ignored.
+	query = NewNamedParameterQuery(queryText, "?")
+	query.SetValuesFromStruct(parameters)
+
+	verifyStructParameters("DbTagsIgnored", test, query, []interface{} {
+		nil,
+		nil,
+		"a@b.c",
+		30,
+		"s",
+		"e",
+		"alice",
+	})
+}
+
+type OmitEmptyParameterTest struct {
+	Name string `sqlParameterName:"name,omitempty"`
+	Age int `sqlParameterName:"age,omitempty"`
+	Active bool `sqlParameterName:",omitempty"`
+	Tags []string `sqlParameterName:"tags,omitempty"`
+	Manager *string `sqlParameterName:"manager,omitempty"`
+	Kept string `sqlParameterName:"kept"`
+	Dash string `sqlParameterName:"-,"`
+}
+
+func TestOmitEmptyStructParameters(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var manager string
+
+	queryText := "SELECT * FROM table WHERE a = :name AND b = :age AND c = :Active AND d IN (:tags) AND e = :manager AND f = :kept AND g = :-"
+
+	query = NewNamedParameterQuery(queryText, "?", WithParameterNameRunes(func(character rune) bool {
+		return character == '-' || unicode.IsLetter(character)
+	}))
+	query.SetValuesFromStruct(OmitEmptyParameterTest{Dash: "dash"})
+
+	verifyStructParameters("OmitEmptyZero", test, query, []interface{} {
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		"",
+		"dash",
+	})
+
+	if(strings.Join(query.MissingParameters(), ",") != "name,age,Active,tags,manager") {
+		test.Log("Test OmitEmptyUnset: Actual: ", query.MissingParameters())
+		test.Fail()
+	}
+
+	manager = ""
+	query = NewNamedParameterQuery(queryText, "?", WithParameterNameRunes(func(character rune) bool {
+		return character == '-' || unicode.IsLetter(character)
+	}))
+	query.SetValuesFromStruct(OmitEmptyParameterTest{Name: "alice", Age: 30, Active: true, Tags: []string{"x"}, Manager: &manager})
+
+	verifyStructParameters("OmitEmptySet", test, query, []interface{} {
+		"alice",
+		30,
+		true,
+		"x",
+		"",
+		"",
+		"",
+	})
+}
+
+type SnakeCaseParameterTest struct {
+	UserID int
+	CreatedAt string
+	Email string `sqlParameterName:"Email"`
+	HomeAddress AddressParameterTest
+}
+
+func TestSnakeCase(test *testing.T) {
+
+	for name, expected := range map[string]string {
+		"CreatedAt": "created_at",
+		"UserID": "user_id",
+		"HTTPStatus": "http_status",
+		"ID": "id",
+		"userId": "user_id",
+		"Address2Line": "address2_line",
+		"APIKeyV2": "api_key_v2",
+		"Name": "name",
+		"": "",
+	} {
+
+		if(SnakeCase(name) != expected) {
+			test.Log("Test SnakeCase: Expected ", expected, " for ", name, ", Actual: ", SnakeCase(name))
+			test.Fail()
+		}
+	}
+}
+
+func TestFieldNameMapper(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var parameters SnakeCaseParameterTest
+
+	parameters = SnakeCaseParameterTest{UserID: 1, CreatedAt: "now", Email: "a@b.c", HomeAddress: AddressParameterTest{City: "Paris", Zip: "75000"}}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE a = :user_id AND b = :created_at AND c = :Email AND d = :home_address.city AND e = :home_address.zip AND f = :UserID", "?", WithSnakeCaseFields())
+	query.SetValuesFromStruct(parameters)
+
+	verifyStructParameters("SnakeCaseFields", test, query, []interface{} {
+		1,
+		"now",
+		"a@b.c",
+		"Paris",
+		"75000",
+		nil,
+	})
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE a = :USERID AND b = :UserID", "?", WithFieldNameMapper(strings.ToUpper))
+	query.SetValuesFromStruct(parameters)
+
+	verifyStructParameters("CustomFieldNames", test, query, []interface{} {
+		1,
+		nil,
+	})
+}
+
+/*
+	A database/sql driver which records the arguments of the statements it executes,
+	after database/sql converted them to driver values.
+*/
+type recordingDriver struct {
+	args []driver.Value
+}
+
+type recordingConn struct {
+	driver *recordingDriver
+}
+
+type recordingStmt struct {
+	driver *recordingDriver
+}
+
+var recorder = &recordingDriver{}
+
+func init() {
+	sql.Register("namedParameterQueryRecorder", recorder)
+}
+
+func (recorder *recordingDriver) Open(name string) (driver.Conn, error) {
+	return &recordingConn{recorder}, nil
+}
+
+func (conn *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{conn.driver}, nil
+}
+
+func (conn *recordingConn) Close() error {
+	return nil
+}
+
+func (conn *recordingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+func (stmt *recordingStmt) Close() error {
+	return nil
+}
+
+func (stmt *recordingStmt) NumInput() int {
+	return -1
+}
+
+func (stmt *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	stmt.driver.args = args
+	return driver.RowsAffected(0), nil
+}
+
+func (stmt *recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("queries are not supported")
+}
+
+/*
+	A value which converts itself for the driver through a pointer only.
+*/
+type CentsParameterTest struct {
+	Cents int64
+}
+
+func (cents *CentsParameterTest) Value() (driver.Value, error) {
+	return cents.Cents, nil
+}
+
+type DriverValueParameterTest struct {
+	Name sql.NullString `sqlParameterName:"name"`
+	Age sql.NullInt64 `sqlParameterName:"age"`
+	Token ValuerParameterTest `sqlParameterName:"token"`
+	Price CentsParameterTest `sqlParameterName:"price"`
+	At *time.Time `sqlParameterName:"at"`
+	Deleted *time.Time `sqlParameterName:"deleted"`
+	Raw []byte `sqlParameterName:"raw"`
+}
+
+func TestStructValuesThroughDriver(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var parameters DriverValueParameterTest
+	var database *sql.DB
+	var at time.Time
+	var err error
+
+	at = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	parameters = DriverValueParameterTest{
+		Name: sql.NullString{String: "alice", Valid: true},
+		Token: ValuerParameterTest{"secret"},
+		Price: CentsParameterTest{150},
+		At: &at,
+		Raw: []byte("raw"),
+	}
+
+	query = NewNamedParameterQuery("UPDATE table SET a = :name, b = :age, c = :token, d = :price, e = :at, f = :deleted, g = :raw", "?")
+	err = query.SetValuesFromStruct(parameters)
+	if(err != nil) {
+		test.Log("Test StructValuesThroughDriver: unexpected error: ", err)
+		test.FailNow()
+	}
+
+	database, err = sql.Open("namedParameterQueryRecorder", "")
+	if(err != nil) {
+		test.Log("Test StructValuesThroughDriver: unexpected error: ", err)
+		test.FailNow()
+	}
+	defer database.Close()
+
+	_, err = database.Exec(query.GetParsedQuery(), query.GetParsedParameters()...)
+	if(err != nil) {
+		test.Log("Test StructValuesThroughDriver: database/sql rejected the values: ", err)
+		test.FailNow()
+	}
+
+	expected := []driver.Value {
+		"alice",
+		nil,
+		"valued:secret",
+		int64(150),
+		at,
+		nil,
+		"raw",
+	}
+
+	if(len(recorder.args) != len(expected)) {
+		test.Log("Test StructValuesThroughDriver: Expected ", expected, ", Actual: ", recorder.args)
+		test.FailNow()
+	}
+
+	for i, value := range recorder.args {
+
+		if raw, isBytes := value.([]byte); isBytes {
+			value = string(raw)
+		}
+
+		if(value != expected[i]) {
+			test.Log("Test StructValuesThroughDriver: Expected ", expected[i], " at ", i, ", Actual: ", value)
+			test.Fail()
+		}
+	}
+}
+
+type OptionalParameterTest struct {
+	Name *string `sqlParameterName:"name"`
+	Age *int64 `sqlParameterName:"age"`
+	Level **int `sqlParameterName:"level"`
+	Any interface{} `sqlParameterName:"any"`
+	Nullable *sql.NullString `sqlParameterName:"nullable"`
+	Price *CentsParameterTest `sqlParameterName:"price"`
+	Omitted *string `sqlParameterName:"omitted,omitempty"`
+}
+
+func TestPointerFieldParameters(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var name string
+	var level int
+	var levelPointer *int
+	var nullable sql.NullString
+	var price CentsParameterTest
+
+	queryText := "SELECT * FROM table WHERE a = :name AND b = :age AND c = :level AND d = :any AND e = :nullable AND f = :price AND g = :omitted"
+
+	name = "alice"
+	level = 3
+	levelPointer = &level
+	nullable = sql.NullString{String: "x", Valid: true}
+	price = CentsParameterTest{150}
+
+	query = NewNamedParameterQuery(queryText, "?")
+	query.SetValuesFromStruct(OptionalParameterTest{Name: &name, Level: &levelPointer, Any: &name, Nullable: &nullable, Price: &price})
+
+	// Valuers are left for database/sql to call.
+	verifyStructParameters("DereferencedFields", test, query, []interface{} {
+		"alice",
+		nil,
+		3,
+		"alice",
+		&nullable,
+		&price,
+		nil,
+	})
+
+	if(!query.IsValueSet("age") || query.IsValueSet("omitted")) {
+		test.Log("Test NilPointerFields: Expected nil pointers to be set as NULL, unless omitted")
+		test.Fail()
+	}
+
+	levelPointer = nil
+	query = NewNamedParameterQuery(queryText, "?")
+	query.SetValuesFromStruct(OptionalParameterTest{Level: &levelPointer, Any: (*string)(nil)})
+
+	// a nil pointer with a pointer receiver Value method is left for database/sql to call too.
+	verifyStructParameters("NilFields", test, query, []interface{} {
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		(*CentsParameterTest)(nil),
+		nil,
+	})
+}
+
+/*
+	A value which converts itself for the driver through a pointer, a nil one included.
+*/
+type NilAwareParameterTest struct {
+	Label string
+}
+
+func (label *NilAwareParameterTest) Value() (driver.Value, error) {
+
+	if label == nil {
+		return "none", nil
+	}
+	return label.Label, nil
+}
+
+func TestNilPointerValuers(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var database *sql.DB
+	var err error
+
+	query = NewNamedParameterQuery("UPDATE table SET a = :label, b = :other, c = :missing, d = :field", "?")
+	query.SetValue("label", (*NilAwareParameterTest)(nil))
+	query.SetValue("other", &NilAwareParameterTest{"x"})
+	query.SetValue("missing", (*ValuerParameterTest)(nil))
+	query.SetValuesFromStruct(struct {
+		Field *NilAwareParameterTest `sqlParameterName:"field"`
+	}{})
+
+	database, err = sql.Open("namedParameterQueryRecorder", "")
+	if(err != nil) {
+		test.Log("Test NilPointerValuers: unexpected error: ", err)
+		test.FailNow()
+	}
+	defer database.Close()
+
+	_, err = database.Exec(query.GetParsedQuery(), query.GetParsedParameters()...)
+	if(err != nil) {
+		test.Log("Test NilPointerValuers: database/sql rejected the values: ", err)
+		test.FailNow()
+	}
+
+	// a value receiver Value method isn't called for a nil pointer, which is a NULL.
+	expected := []driver.Value {"none", "x", nil, "none"}
+
+	if(len(recorder.args) != len(expected)) {
+		test.Log("Test NilPointerValuers: Expected ", expected, ", Actual: ", recorder.args)
+		test.FailNow()
+	}
+
+	for i, value := range recorder.args {
+
+		if(value != expected[i]) {
+			test.Log("Test NilPointerValuers: Expected ", expected[i], " at ", i, ", Actual: ", value)
+			test.Fail()
+		}
+	}
+}
+
+type SkippedParameterTest struct {
+	Foo string
+	Cache string `sqlParameterName:"-"`
+	Computed int `sqlParameterName:"-"`
+}
+
+func TestSkippedStructParameters(test *testing.T) {
+
+	var query *NamedParameterQuery
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :Foo AND col2 = :Cache AND col3 = :Computed AND col4 = :-", "?")
+	query.SetValuesFromStruct(SkippedParameterTest{Foo: "foo", Cache: "cache", Computed: 5})
+
+	verifyStructParameters("SkippedFields", test, query, []interface{} {
+		"foo",
+		nil,
+		nil,
+	})
+}
+
+func TestPointerStructParameters(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var parameters *SingleParameterTest
+	var err error
+
+	queryText := "SELECT * FROM table WHERE col1 = :Foo AND col2 = :Baz"
+
+	query = NewNamedParameterQuery(queryText, "?")
+	err = query.SetValuesFromStruct(SingleParameterTest{Foo: "foo", Baz: 1})
+
+	if(err != nil) {
+		test.Log("Test ValueStruct: unexpected error: ", err)
+		test.Fail()
+	}
+
+	verifyStructParameters("ValueStruct", test, query, []interface{} {
+		"foo",
+		1,
+	})
+
+	query = NewNamedParameterQuery(queryText, "?")
+	err = query.SetValuesFromStruct(&SingleParameterTest{Foo: "bar", Baz: 2})
+
+	if(err != nil) {
+		test.Log("Test PointerStruct: unexpected error: ", err)
+		test.Fail()
+	}
+
+	verifyStructParameters("PointerStruct", test, query, []interface{} {
+		"bar",
+		2,
+	})
+
+	parameters = &SingleParameterTest{Foo: "baz", Baz: 3}
+	wrapped := interface{}(parameters)
+
+	for name, value := range map[string]interface{} {
+		"DoublePointerStruct": &parameters,
+		"InterfacePointerStruct": &wrapped,
+	} {
+
+		query = NewNamedParameterQuery(queryText, "?")
+		err = query.SetValuesFromStruct(value)
+
+		if(err != nil) {
+			test.Log("Test ", name, ": unexpected error: ", err)
+			test.Fail()
+		}
+
+		verifyStructParameters(name, test, query, []interface{} {
+			"baz",
+			3,
+		})
+	}
+
+	parameters = nil
+	wrapped = nil
+
+	for name, value := range map[string]interface{} {
+		"NilPointerStruct": parameters,
+		"NilDoublePointerStruct": &parameters,
+		"NilInterfaceStruct": &wrapped,
+	} {
+
+		query = NewNamedParameterQuery(queryText, "?")
+		err = query.SetValuesFromStruct(value)
+
+		if(err == nil || !strings.Contains(err.Error(), "nil")) {
+			test.Log("Test ", name, ": expected an error for a nil pointer, Actual: ", err)
+			test.Fail()
+		}
+	}
+
+	err = query.SetValuesFromStruct("foo")
+
+	if(err == nil) {
+		test.Log("Test NotAStruct: expected an error for a string")
+		test.Fail()
+	}
+}
+
+/*
+	A struct which converts itself for the driver.
+*/
+type ValuerParameterTest struct {
+	Secret string
+}
+
+func (valuer ValuerParameterTest) Value() (driver.Value, error) {
+	return "valued:" + valuer.Secret, nil
+}
+
+type EventParameterTest struct {
+	At time.Time `sqlParameterName:"at"`
+	Range struct {
+		From time.Time `sqlParameterName:"from"`
+	} `sqlParameterName:"range"`
+	Valuer ValuerParameterTest `sqlParameterName:"valuer"`
+}
+
+type EmbeddedTimeParameterTest struct {
+	time.Time
+	Name string `sqlParameterName:"name"`
+}
+
+func TestValueTypeStructParameters(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var parameters EventParameterTest
+	var err error
+
+	at := time.Date(2016, 3, 1, 10, 30, 0, 0, time.UTC)
+	from := time.Date(2016, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	parameters.At = at
+	parameters.Range.From = from
+	parameters.Valuer = ValuerParameterTest{Secret: "s"}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :at AND col2 = :range.from AND col3 = :valuer AND col4 = :valuer.secret", "?")
+	err = query.SetValuesFromStruct(parameters)
+
+	if(err != nil) {
+		test.Log("Test ValueTypeStruct: unexpected error: ", err)
+		test.Fail()
+	}
+
+	// the valuer is bound as it is, and never walked.
+	verifyStructParameters("ValueTypeStruct", test, query, []interface{} {
+		at,
+		from,
+		ValuerParameterTest{Secret: "s"},
+		nil,
+	})
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :Time AND col2 = :name", "?")
+	err = query.SetValuesFromStruct(&EmbeddedTimeParameterTest{Time: at, Name: "n"})
+
+	if(err != nil) {
+		test.Log("Test EmbeddedTime: unexpected error: ", err)
+		test.Fail()
+	}
+
+	verifyStructParameters("EmbeddedTime", test, query, []interface{} {
+		at,
+		"n",
+	})
+}
+
+func TestStrictStructParameters(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	parameters := NestedParameterTest {
+		EmbeddedParameterTest: EmbeddedParameterTest{Limit: 10, Offset: 20},
+		Name: "name",
+		Address: AddressParameterTest{City: "city", Zip: "zip"},
+		Untagged: AddressParameterTest{City: "other", Zip: "other"},
+	}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE a = :limit AND b = :Offset AND c = :name AND d = :addr.city AND e = :addr.zip AND f = :Untagged", "?")
+	err = query.SetValuesFromStructStrict(parameters)
+
+	if(err != nil) {
+		test.Log("Test StrictStruct: unexpected error: ", err)
+		test.Fail()
+	}
+
+	// untagged fields are left unset, even inside tagged nested structs.
+	verifyStructParameters("StrictStruct", test, query, []interface{} {
+		10,
+		nil,
+		"name",
+		"city",
+		nil,
+		nil,
+	})
+
+	if(query.IsValueSet("Offset") || query.IsValueSet("Untagged")) {
+		test.Log("Test StrictStructUnset: Expected untagged fields not to be set")
+		test.Fail()
+	}
+}
+
+type DuplicateParameterTest struct {
+	First string `sqlParameterName:"name"`
+	Second string `sqlParameterName:"name"`
+}
+
+type ShadowingParameterTest struct {
+	EmbeddedParameterTest
+	Limit int `sqlParameterName:"limit"`
+}
+
+func TestDuplicateStructParameters(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :name", "?")
+	err = query.SetValuesFromStruct(DuplicateParameterTest{First: "first", Second: "second"})
+
+	if(err == nil || !strings.Contains(err.Error(), "First") || !strings.Contains(err.Error(), "Second")) {
+		test.Log("Test DuplicateTags: Expected an error naming both fields, Actual: ", err)
+		test.Fail()
+	}
+
+	if(query.IsValueSet("name")) {
+		test.Log("Test DuplicateTagsNotSet: Expected no value to be set")
+		test.Fail()
+	}
+
+	// a field shadows the one of the same name in an embedded struct, as in Go.
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :limit AND col2 = :Offset", "?")
+	err = query.SetValuesFromStruct(ShadowingParameterTest{EmbeddedParameterTest: EmbeddedParameterTest{Limit: 1, Offset: 2}, Limit: 3})
+
+	if(err != nil) {
+		test.Log("Test ShadowedField: unexpected error: ", err)
+		test.Fail()
+	}
+
+	verifyStructParameters("ShadowedField", test, query, []interface{} {
+		3,
+		2,
+	})
+}
+
+type InlineMapParameterTest struct {
+	Name string `sqlParameterName:"name"`
+	Extra map[string]interface{} `sqlParameterName:",inline"`
+	Labels map[string]string `sqlParameterName:"labels,inline"`
+	Ignored map[string]interface{}
+}
+
+func TestInlineMapStructParameters(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	parameters := InlineMapParameterTest {
+		Name: "name",
+		Extra: map[string]interface{} {
+			"status": "open",
+			"name": "shadowed",
+			"user": map[string]interface{} {"id": 7},
+			"none": nil,
+		},
+		Labels: map[string]string {"team": "core"},
+		Ignored: map[string]interface{} {"hidden": 1},
+	}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE a = :name AND b = :status AND c = :user.id AND d = :none AND e = :labels.team AND f = :hidden", "?")
+	err = query.SetValuesFromStructStrict(&parameters)
+
+	if(err != nil) {
+		test.Log("Test InlineMap: unexpected error: ", err)
+		test.Fail()
+	}
+
+	// fields beside the map shadow its entries, and untagged maps are not inlined.
+	verifyStructParameters("InlineMap", test, query, []interface{} {
+		"name",
+		"open",
+		7,
+		nil,
+		"core",
+		nil,
+	})
+
+	if(!query.IsValueSet("none") || query.IsValueSet("hidden")) {
+		test.Log("Test InlineMapSet: Expected every entry of inline maps to be set, and only those")
+		test.Fail()
+	}
+
+	// a name in the tag prefixes the entries, and without one they aren't prefixed.
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE a = :team AND b = :labels.team AND c = :tags.team AND d = :Tags.team", "?")
+	err = query.SetValuesFromStruct(struct {
+		Labels map[string]string `sqlParameterName:"labels,inline"`
+		Tags map[string]string `sqlParameterName:",inline" sqlParameterPrefix:"tags"`
+		Unprefixed map[string]string `sqlParameterName:",inline"`
+	}{
+		Labels: map[string]string {"team": "labelled"},
+		Tags: map[string]string {"team": "tagged"},
+		Unprefixed: map[string]string {"team": "unprefixed"},
+	})
+
+	if(err != nil) {
+		test.Log("Test InlineMapPrefix: unexpected error: ", err)
+		test.Fail()
+	}
+	verifyStructParameters("InlineMapPrefix", test, query, []interface{} {"unprefixed", "labelled", "tagged", nil})
+
+	// entries are prefixed as the fields of a nested struct are.
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE a = :filters.status AND b = :status", "?")
+	err = query.SetValuesFromStruct(struct {
+		Filters struct {
+			Extra map[string]interface{} `sqlParameterName:",inline"`
+		}
+	}{Filters: struct {
+		Extra map[string]interface{} `sqlParameterName:",inline"`
+	}{Extra: map[string]interface{} {"status": "closed"}}})
+
+	if(err != nil || !query.IsValueSet("filters.status") || query.IsValueSet("status")) {
+		test.Log("Test NestedInlineMap: Actual: ", query.String(), err)
+		test.Fail()
+	}
+
+	// a map holding itself is walked once.
+	cycle := map[string]interface{} {"id": 1}
+	cycle["self"] = cycle
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE a = :id AND b = :self.id", "?")
+	err = query.SetValuesFromStruct(InlineMapParameterTest{Extra: cycle})
+
+	if(err != nil || !query.IsValueSet("id") || query.IsValueSet("self.id")) {
+		test.Log("Test InlineMapCycle: Actual: ", query.String(), err)
+		test.Fail()
+	}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE a = :status", "?", WithCaseInsensitiveNames())
+	err = query.SetValuesFromStruct(InlineMapParameterTest{Extra: map[string]interface{} {"status": 1, "STATUS": 2}})
+
+	if(err == nil) {
+		test.Log("Test InlineMapDuplicates: Expected an error for entries differing by case only")
+		test.Fail()
+	}
+}
@@ -0,0 +1,77 @@
+package namedParameterQuery
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+/*
+	SetValuesFromURLValues sets parameter values from the given [values], e.g. the query string of a request
+	or a parsed form: a key given once is bound as a string, and a key given several times as a []string,
+	which is expanded as any slice, e.g. for "IN (:status)" with "?status=open&status=closed".
+	A key given with an empty value is bound as an empty string, or as NULL with WithEmptyURLValuesAsNull.
+	Keys which aren't parameters of the query are ignored, as are those without any value.
+	If a key given several times can't be bound as a slice, because slices are not expanded,
+	an error is returned and no value is set.
+*/
+func (npq *NamedParameterQuery) SetValuesFromURLValues(values url.Values) error {
+
+	var parameters map[string]interface{}
+	var given []string
+	var present bool
+	var err error
+
+	parameters = make(map[string]interface{}, len(npq.namedParameters))
+
+	for _, parameter := range npq.namedParameters {
+
+		given, present = urlValue(values, parameter.name, npq.caseInsensitive)
+
+		switch {
+		case !present || len(given) == 0:
+			continue
+
+		case len(given) > 1:
+
+			err = npq.checkValue(given)
+			if err != nil {
+				return fmt.Errorf("unable to add query values from URL values: parameter '%s' is given %d values: %v", parameter.name, len(given), err)
+			}
+			parameters[parameter.name] = given
+
+		case given[0] == "" && npq.emptyURLValuesAsNull:
+			parameters[parameter.name] = nil
+
+		default:
+			parameters[parameter.name] = given[0]
+		}
+	}
+
+	for name, value := range parameters {
+		npq.SetValue(name, value)
+	}
+	return nil
+}
+
+/*
+	urlValue returns the values of [key] in [values]. If [foldCase] is true and there is no exact match,
+	the first key equal to [key] regardless of case is used.
+*/
+func urlValue(values url.Values, key string, foldCase bool) ([]string, bool) {
+
+	var given []string
+	var present bool
+
+	given, present = values[key]
+	if present || !foldCase {
+		return given, present
+	}
+
+	for candidate, given := range values {
+		if strings.EqualFold(candidate, key) {
+			return given, true
+		}
+	}
+	return nil, false
+}
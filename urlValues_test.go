@@ -0,0 +1,74 @@
+package namedParameterQuery
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestSetValuesFromURLValues(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var values url.Values
+	var err error
+
+	values, err = url.ParseQuery("name=Alice&status=open&status=closed&note=&unused=1&none")
+	if(err != nil) {
+		test.Log("Test URLValues: unexpected error: ", err)
+		test.FailNow()
+	}
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :name AND col2 IN (:status) AND col3 = :note AND col4 = :missing", "$")
+
+	err = query.SetValuesFromURLValues(values)
+	if(err != nil) {
+		test.Log("Test URLValues: unexpected error: ", err)
+		test.Fail()
+	}
+
+	if(query.GetParsedQuery() != "SELECT * FROM table WHERE col1 = $1 AND col2 IN ($2, $3) AND col3 = $4 AND col4 = $5") {
+		test.Log("Test URLValuesQuery: Actual: ", query.GetParsedQuery())
+		test.Fail()
+	}
+	verifyParameters("URLValues", test, query.GetParsedParameters(), []interface{} {"Alice", "open", "closed", "", nil})
+
+	if(query.IsValueSet("missing")) {
+		test.Log("Test URLValuesMissing: Expected only the given keys to be set")
+		test.Fail()
+	}
+
+	// an empty value is NULL when asked so, and a key without a value, i.e. no "=", is empty too.
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :note AND col2 = :none", "?", WithEmptyURLValuesAsNull())
+	query.SetDefault("unset")
+
+	err = query.SetValuesFromURLValues(values)
+	if(err != nil || !query.IsValueSet("note") || !query.IsValueSet("none")) {
+		test.Log("Test URLValuesEmptyAsNull: unexpected error: ", err)
+		test.Fail()
+	}
+	verifyParameters("URLValuesEmptyAsNull", test, query.GetParsedParameters(), []interface{} {nil, nil})
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :NAME", "?", WithCaseInsensitiveNames())
+	query.SetValuesFromURLValues(url.Values{"Name": {"Bob"}, "empty": {}})
+	verifyParameters("URLValuesCaseInsensitive", test, query.GetParsedParameters(), []interface{} {"Bob"})
+}
+
+func TestSetValuesFromURLValuesWithoutExpansion(test *testing.T) {
+
+	var query *NamedParameterQuery
+	var err error
+
+	query = NewNamedParameterQuery("SELECT * FROM table WHERE col1 = :name AND col2 = ANY(:status)", "$", WithoutSliceExpansion())
+
+	err = query.SetValuesFromURLValues(url.Values{"name": {"Alice"}, "status": {"open", "closed"}})
+	if(err == nil || query.IsValueSet("name")) {
+		test.Log("Test URLValuesWithoutExpansion: Expected an error and no value set, Actual: ", err)
+		test.Fail()
+	}
+
+	err = query.SetValuesFromURLValues(url.Values{"name": {"Alice"}, "status": {"open"}})
+	if(err != nil) {
+		test.Log("Test URLValuesSingleWithoutExpansion: unexpected error: ", err)
+		test.Fail()
+	}
+	verifyParameters("URLValuesSingleWithoutExpansion", test, query.GetParsedParameters(), []interface{} {"Alice", "open"})
+}